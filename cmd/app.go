@@ -2,70 +2,410 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
-	"github.com/rs/cors"
 	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/attachment"
+	attachmentdb "go.mod/internal/apps/attachment/db"
 	"go.mod/internal/apps/category"
 	categorydb "go.mod/internal/apps/category/db"
+	categorymongo "go.mod/internal/apps/category/mongodb"
+	"go.mod/internal/apps/comment"
+	commentdb "go.mod/internal/apps/comment/db"
 	"go.mod/internal/apps/product"
 	productdb "go.mod/internal/apps/product/db"
+	productmongo "go.mod/internal/apps/product/mongodb"
+	"go.mod/internal/apps/reaction"
+	reactiondb "go.mod/internal/apps/reaction/db"
+	"go.mod/internal/apps/signup"
 	"go.mod/internal/apps/user"
 	"go.mod/internal/apps/user/db"
+	usermongo "go.mod/internal/apps/user/mongodb"
 	"go.mod/internal/config"
+	"go.mod/internal/middleware"
+	"go.mod/migrations"
+	"go.mod/pkg/blobstore"
+	"go.mod/pkg/blobstore/local"
+	"go.mod/pkg/blobstore/s3"
+	"go.mod/pkg/cache"
 	"go.mod/pkg/cache/freecache"
+	"go.mod/pkg/cache/lru"
+	"go.mod/pkg/cache/pgnotify"
+	"go.mod/pkg/client/mongodb"
 	"go.mod/pkg/client/postgresql"
 	"go.mod/pkg/jwt"
 	"go.mod/pkg/logging"
+	"go.mod/pkg/metrics"
+	"go.mod/pkg/migrate"
+	"go.mod/pkg/tracing"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"syscall"
 	"time"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	logger := logging.GetLogger()
 	logger.Info("Server is starting")
 	router := httprouter.New()
 
-	cfg := config.GetConfig()
-	logger.Info("read application configs")
+	path, profile := config.PathFromEnv(config.DefaultPath, "")
+	flag.StringVar(&path, "config", path, "configuration file, also set by APP_CONFIG")
+	flag.StringVar(&profile, "profile", profile, "overlay config.<profile>.yaml next to the configuration file, also set by APP_PROFILE")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags]\n       %s migrate <command>\n\nflags:\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output(), "\nenvironment overrides:")
+		config.Usage(flag.CommandLine.Output())
+	}
+	flag.Parse()
+
+	cfg, err := config.Load(path, profile)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.Infof("read application configs from %s", path)
+	apperror.SetDebug(cfg.IsDebug)
+
+	closeLogFile, err := logging.Init(logging.Options{
+		Format:   cfg.Logging.Format,
+		Level:    cfg.Logging.Level,
+		Packages: cfg.Logging.Packages,
+		Outputs:  cfg.Logging.Outputs,
+		File: logging.FileOptions{
+			Path:       cfg.Logging.File.Path,
+			MaxSizeMB:  cfg.Logging.File.MaxSizeMB,
+			MaxBackups: cfg.Logging.File.MaxBackups,
+			MaxAgeDays: cfg.Logging.File.MaxAgeDays,
+			Compress:   cfg.Logging.File.Compress,
+		},
+		SampleInitial:    cfg.Logging.Sampling.Initial,
+		SampleThereafter: cfg.Logging.Sampling.Thereafter,
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer closeLogFile()
+	logger.Debugf("configuration: %v", cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("set up tracing")
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		File:        cfg.Tracing.File,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
 
 	router.ServeFiles("/swagger/*filepath", http.Dir("docs"))
 
-	postgresClient, err := postgresql.NewClient(context.Background(), 3, cfg)
+	postgresPool, err := postgresql.NewClient(ctx, cfg)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	replicaPools, err := postgresql.NewReplicas(cfg)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	postgresClient := postgresql.NewUnitOfWork(postgresPool, logger, replicaPools...)
+	go postgresClient.WatchReplicas(ctx, cfg.Storage.ReplicaCheckInterval, cfg.Storage.ReplicaMaxLag)
+	if cfg.Migrations.Auto {
+		logger.Info("apply database migrations")
+		migrator, err := migrate.NewMigrator(postgresPool, migrations.FS, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		if err := migrator.Up(ctx, 0); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	// TinyLFU would reject new refresh tokens once the cache is full, they
+	// are only ever read once
+	refreshTokenBackend := cfg.Cache.Backend
+	if refreshTokenBackend == lru.TinyLFU {
+		refreshTokenBackend = lru.LRU
+	}
+	refreshTokenCache := newCache(refreshTokenBackend, cfg.Cache.RefreshTokens.Size, cfg.Cache.RefreshTokens.MaxEntries)
+	entityCache := newCache(cfg.Cache.Backend, cfg.Cache.Size, cfg.Cache.MaxEntries)
+	readThrough := cache.NewReadThrough(entityCache, cache.Options{
+		TTL:         cfg.Cache.TTL,
+		NegativeTTL: cfg.Cache.NegativeTTL,
+		NotFound:    apperror.ErrorNotFound,
+	})
+	invalidations := pgnotify.NewBus(postgresClient, postgresPool, logger)
+	go invalidations.Subscribe(ctx, readThrough.Evict)
+
+	repositories, err := newRepositories(ctx, cfg, postgresClient, invalidations, logger)
 	if err != nil {
 		logger.Fatal(err)
 	}
-	userRepository := db.NewUserRepository(postgresClient, logger)
+	userRepository := repositories.users
 
-	refreshTokenCache := freecache.NewCacheRepo(104857600) // 100MB
 	logger.Info("Register User api")
 	jwtHelper := jwt.NewHelper(refreshTokenCache, logger)
-	userService := user.NewUserService(userRepository, logger)
+	userService := user.NewTracedService(user.NewUserService(userRepository, logger))
 	userHandler := api.NewUserHandler(*logger, userService, jwtHelper)
 	userHandler.Register(router)
 
 	logger.Info("Register Product api")
-	productRepository := productdb.NewProductRepository(postgresClient, logger)
-	productService := product.NewService(productRepository, logger)
+	productRepository := repositories.products
+	productService := product.NewTracedService(product.NewCachedService(product.NewService(productRepository, postgresClient, logger), readThrough))
 	productHandler := api.NewPostHandler(logger, productService)
 	productHandler.Register(router)
 
 	logger.Info("Register Category api")
-	categoryRepository := categorydb.NewCategoryRepository(postgresClient, logger)
-	categoryService := category.NewService(categoryRepository, logger)
+	categoryRepository := repositories.categories
+	categoryService := category.NewTracedService(category.NewCachedService(category.NewService(categoryRepository, logger), readThrough))
 	categoryHandler := api.NewCategoryHandler(logger, categoryService)
 	categoryHandler.Register(router)
 
-	start(router, cfg, logger)
+	// comments, reactions, bookmarks, attachments and signups reference users
+	// and products in Postgres and need them in the same database
+	if cfg.Storage.Backend == "postgres" {
+		registerPostgresApis(router, cfg, postgresClient, invalidations, userService, productService, logger)
+	} else {
+		logger.Warnf("storage backend %s: signup, reaction, comment and attachment apis are disabled", cfg.Storage.Backend)
+	}
+
+	logger.Info("Register Health api")
+	healthChecks := map[string]api.HealthCheck{
+		"postgres":             postgresPool.Ping,
+		"refresh_tokens_cache": cacheCheck(refreshTokenCache),
+		"entities_cache":       cacheCheck(entityCache),
+	}
+	for name, check := range repositories.checks {
+		healthChecks[name] = check
+	}
+	healthHandler := api.NewHealthHandler(logger, healthChecks)
+	healthHandler.Register(router)
+
+	logger.Info("Register Logging api")
+	loggingHandler := api.NewLoggingHandler(logger)
+	loggingHandler.Register(router)
+
+	logger.Info("Register Cache api")
+	cacheHandler := api.NewCacheHandler(logger, map[string]cache.Repository{
+		"refresh_tokens": refreshTokenCache,
+		"entities":       entityCache,
+	})
+	cacheHandler.Register(router)
+
+	logger.Info("Register metrics")
+	metrics.MustRegister(
+		postgresql.NewPoolCollector(postgresPool),
+		cache.NewCollector("refresh_tokens", refreshTokenCache),
+		cache.NewCollector("entities", entityCache),
+	)
+	router.Handler(http.MethodGet, "/metrics", metrics.Handler())
+
+	corsPolicy := middleware.NewCORS(cfg.CORS.AllowedOrigins)
+	go reloadOnHangup(ctx, corsPolicy, logger)
+
+	if err := start(ctx, router, corsPolicy, healthHandler, cfg, logger); err != nil {
+		logger.Error(err)
+	}
+
+	logger.Info("close database pool")
+	postgresPool.Close()
+	for _, pool := range replicaPools {
+		pool.Close()
+	}
+	repositories.close()
+	logger.Info("close cache")
+	if err := refreshTokenCache.Close(); err != nil {
+		logger.Error(err)
+	}
+	if err := entityCache.Close(); err != nil {
+		logger.Error(err)
+	}
+	logger.Info("flush traces")
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error(err)
+	}
+	logger.Info("application stopped")
+}
+
+// reloadOnHangup re-reads the configuration on SIGHUP and applies the
+// settings that can change at runtime: log levels and CORS origins.
+func reloadOnHangup(ctx context.Context, corsPolicy *middleware.CORS, logger *logging.Logger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+		reloaded, err := config.Reload()
+		if err != nil {
+			logger.Errorf("configuration not reloaded: %v", err)
+			continue
+		}
+		if err := logging.SetLevels(reloaded.Logging.Level, reloaded.Logging.Packages); err != nil {
+			logger.Errorf("log levels not reloaded: %v", err)
+		}
+		corsPolicy.SetOrigins(reloaded.CORS.AllowedOrigins)
+		logger.Infof("configuration reloaded: log level %s, cors origins %v", reloaded.Logging.Level, reloaded.CORS.AllowedOrigins)
+	}
+}
+
+// cacheCheck writes and deletes a probe entry, it leaves hit and miss
+// counters untouched.
+func cacheCheck(c cache.Repository) api.HealthCheck {
+	return func(ctx context.Context) error {
+		key := []byte("readiness-probe")
+		if err := c.Set(key, []byte{1}, 5); err != nil {
+			// a full TinyLFU cache turns new keys away and still serves
+			if errors.Is(err, lru.ErrRejected) {
+				return nil
+			}
+			return err
+		}
+		if !c.Del(key) {
+			return errors.New("probe entry was not stored")
+		}
+		return nil
+	}
+}
+
+// registerPostgresApis registers the apis whose tables reference users and
+// products, only available when those are stored in Postgres as well.
+func registerPostgresApis(router *httprouter.Router, cfg *config.Config, postgresClient *postgresql.UnitOfWork, invalidations cache.Publisher, userService user.Service, productService product.Service, logger *logging.Logger) {
+	logger.Info("Register Signup api")
+	signupService := signup.NewTracedService(signup.NewService(userService, productService, postgresClient, logger))
+	signupHandler := api.NewSignupHandler(logger, signupService)
+	signupHandler.Register(router)
+
+	logger.Info("Register Reaction api")
+	reactionRepository := reactiondb.NewReactionRepository(postgresClient, invalidations, logger)
+	reactionService := reaction.NewTracedService(reaction.NewService(reactionRepository, logger))
+	reactionHandler := api.NewReactionHandler(logger, reactionService)
+	reactionHandler.Register(router)
+
+	logger.Info("Register Comment api")
+	commentRepository := commentdb.NewCommentRepository(postgresClient, logger)
+	commentService := comment.NewTracedService(comment.NewService(commentRepository, logger))
+	commentHandler := api.NewCommentHandler(logger, commentService)
+	commentHandler.Register(router)
+
+	logger.Info("Register Attachment api")
+	blobStore, err := newBlobStore(cfg)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	attachmentRepository := attachmentdb.NewAttachmentRepository(postgresClient, logger)
+	attachmentService := attachment.NewTracedService(attachment.NewService(attachmentRepository, productService, blobStore, attachment.Options{
+		MaxSize:    cfg.Media.MaxSize,
+		MaxWidth:   cfg.Media.MaxWidth,
+		MaxHeight:  cfg.Media.MaxHeight,
+		URLTTL:     time.Duration(cfg.Media.URLTTL) * time.Second,
+		SigningKey: []byte(cfg.Media.SigningKey),
+	}, logger))
+	attachmentHandler := api.NewAttachmentHandler(logger, attachmentService)
+	attachmentHandler.Register(router)
+}
+
+// repositories are the storages of users, products and categories, kept in
+// the backend chosen by storage.backend.
+type repositories struct {
+	users      user.Storage
+	products   product.Storage
+	categories category.Storage
+	checks     map[string]api.HealthCheck
+	close      func()
+}
+
+// newRepositories builds the storages of the configured backend. Product and
+// category writes publish cache invalidations to publisher with either one.
+func newRepositories(ctx context.Context, cfg *config.Config, client *postgresql.UnitOfWork, publisher cache.Publisher, logger *logging.Logger) (repositories, error) {
+	switch cfg.Storage.Backend {
+	case "mongodb":
+		logger.Info("connect to mongodb")
+		m := cfg.MongoDB
+		connectCtx, cancel := context.WithTimeout(ctx, cfg.Storage.ConnectTimeout)
+		defer cancel()
+		database, err := mongodb.NewClient(connectCtx, m.Host, m.Port, m.Username, m.Password, m.Database, m.AuthDB)
+		if err != nil {
+			return repositories{}, err
+		}
+		r := repositories{
+			checks: map[string]api.HealthCheck{
+				"mongodb": func(ctx context.Context) error { return database.Client().Ping(ctx, nil) },
+			},
+			close: func() { database.Client().Disconnect(context.Background()) },
+		}
+		if r.users, err = usermongo.NewUserRepository(ctx, database, logger); err != nil {
+			return r, err
+		}
+		if r.products, err = productmongo.NewProductRepository(ctx, database, publisher, logger); err != nil {
+			return r, err
+		}
+		if r.categories, err = categorymongo.NewCategoryRepository(ctx, database, publisher, logger); err != nil {
+			return r, err
+		}
+		return r, nil
+	case "postgres", "":
+		return repositories{
+			users:      db.NewUserRepository(client, logger),
+			products:   productdb.NewProductRepository(client, publisher, logger),
+			categories: categorydb.NewCategoryRepository(client, publisher, logger),
+			close:      func() {},
+		}, nil
+	}
+	return repositories{}, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+}
+
+// newCache returns a cache of the configured backend, size is in bytes and
+// maxEntries only bounds the lru and tinylfu backends.
+func newCache(backend string, size, maxEntries int) cache.Repository {
+	switch backend {
+	case "lru", "tinylfu":
+		return lru.NewCacheRepo(lru.Options{MaxEntries: maxEntries, MaxBytes: int64(size), Policy: backend})
+	}
+	return freecache.NewCacheRepo(size)
+}
+
+func newBlobStore(cfg *config.Config) (blobstore.BlobStore, error) {
+	switch cfg.Media.Backend {
+	case "s3":
+		s3cfg := cfg.Media.S3
+		return s3.NewBlobStore(context.Background(), s3cfg.Endpoint, s3cfg.Region, s3cfg.Bucket, s3cfg.AccessKey, s3cfg.SecretKey, s3cfg.UseSSL)
+	case "local", "":
+		return local.NewBlobStore(cfg.Media.LocalDir)
+	}
+	return nil, fmt.Errorf("unknown media backend %q", cfg.Media.Backend)
 }
 
-func start(router *httprouter.Router, cfg *config.Config, logger *logging.Logger) {
+// start serves until ctx is cancelled, then stops accepting connections and
+// waits up to the shutdown timeout for in-flight requests to complete.
+func start(ctx context.Context, router *httprouter.Router, corsPolicy *middleware.CORS, health api.HealthHandler, cfg *config.Config, logger *logging.Logger) error {
 
 	logger.Info("start application")
 	logger.Infof("server is listening port %s:%s", cfg.Listen.BindIp, cfg.Listen.Port)
@@ -79,11 +419,14 @@ func start(router *httprouter.Router, cfg *config.Config, logger *logging.Logger
 		appDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 		logger.Info(appDir)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		logger.Info("create socket")
 
 		socketPath := path.Join(appDir, "app.sock")
+		if err := removeStaleSocket(socketPath); err != nil {
+			return err
+		}
 
 		listener, ListenError = net.Listen("unix", socketPath)
 
@@ -95,13 +438,66 @@ func start(router *httprouter.Router, cfg *config.Config, logger *logging.Logger
 	}
 
 	if ListenError != nil {
-		panic(ListenError)
+		return ListenError
 	}
-	handler := cors.Default().Handler(router)
+	handler := middleware.Chain(corsPolicy.Handler(router),
+		middleware.RequestID(logger),
+		middleware.Tracing(router),
+		middleware.AccessLog(router),
+		middleware.Metrics(router),
+		middleware.Timeout(router, cfg.HTTP.RequestTimeout, cfg.HTTP.RouteTimeouts),
+		middleware.Recover,
+		middleware.ReadYourWrites,
+	)
 	server := http.Server{
 		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
-	log.Fatal(server.Serve(listener))
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Infof("shutdown signal received, failing readiness for %s", cfg.Listen.DrainDelay)
+	health.Drain()
+	time.Sleep(cfg.Listen.DrainDelay)
+	logger.Infof("draining requests for up to %s", cfg.Listen.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Listen.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("requests did not drain in %s: %w", cfg.Listen.ShutdownTimeout, err)
+	}
+	logger.Info("server stopped")
+	return nil
+}
+
+// removeStaleSocket deletes a socket file left behind by a process that did
+// not exit cleanly. A socket that still accepts connections belongs to a
+// running instance and is left alone.
+func removeStaleSocket(socketPath string) error {
+	info, err := os.Stat(socketPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", socketPath)
+	}
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", socketPath)
+	}
+	return os.Remove(socketPath)
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go.mod/internal/config"
+	"go.mod/migrations"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/logging"
+	"go.mod/pkg/migrate"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `usage: app migrate <command> [arguments]
+
+commands:
+  up [N]          apply all pending migrations, or only the next N
+  down [N]        revert the last applied migration, or the last N
+  status          list migrations and when they were applied
+  create <name>   write an empty migration pair into -dir
+`
+
+// runMigrate implements the "migrate" subcommand of the binary.
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := flags.String("dir", "migrations", "directory new migrations are created in")
+	path, profile := config.PathFromEnv(config.DefaultPath, "")
+	flags.StringVar(&path, "config", path, "configuration file, also set by APP_CONFIG")
+	flags.StringVar(&profile, "profile", profile, "configuration profile, also set by APP_PROFILE")
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage); flags.PrintDefaults() }
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	command := flags.Arg(0)
+
+	if command == "create" {
+		if flags.NArg() < 2 {
+			return fmt.Errorf("migrate create needs a name")
+		}
+		up, down, err := migrate.Create(*dir, flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created %s\ncreated %s\n", up, down)
+		return nil
+	}
+
+	steps := 0
+	if flags.NArg() > 1 {
+		n, err := strconv.Atoi(flags.Arg(1))
+		if err != nil || n < 1 {
+			return fmt.Errorf("number of steps must be a positive number")
+		}
+		steps = n
+	}
+
+	logger := logging.GetLogger()
+	cfg, err := config.Load(path, profile)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	pool, err := postgresql.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := migrate.NewMigrator(pool, migrations.FS, logger)
+	if err != nil {
+		return err
+	}
+	switch command {
+	case "up":
+		return migrator.Up(ctx, steps)
+	case "down":
+		return migrator.Down(ctx, steps)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-45s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	}
+	flags.Usage()
+	return fmt.Errorf("unknown migrate command %q", command)
+}
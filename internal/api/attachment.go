@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"go.mod/internal"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/attachment"
+	"go.mod/pkg/jwt"
+	"go.mod/pkg/logging"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	productAttachmentsUrl = "/products/attachments/"
+	productAttachmentUrl  = "/products/attachments/id/"
+	mediaUrl              = "/media/"
+
+	// discardTimeout bounds the cleanup of a failed multi-file upload.
+	discardTimeout = 30 * time.Second
+)
+
+type attachmentHandler struct {
+	logger  *logging.Logger
+	service attachment.Service
+}
+
+func NewAttachmentHandler(logger *logging.Logger, s attachment.Service) internal.Handler {
+	return &attachmentHandler{
+		logger:  logger,
+		service: s,
+	}
+}
+
+func (h attachmentHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, productAttachmentsUrl, apperror.Middleware(h.GetList))
+	router.HandlerFunc(http.MethodPost, productAttachmentsUrl, jwt.Middleware(apperror.Middleware(h.Upload)))
+	router.HandlerFunc(http.MethodDelete, productAttachmentUrl, jwt.Middleware(apperror.Middleware(h.Delete)))
+	router.HandlerFunc(http.MethodGet, mediaUrl, apperror.Middleware(h.Download))
+}
+
+func (h attachmentHandler) GetList(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	productId, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	attachments, err := h.service.FindProductAttachments(request.Context(), productId)
+	if err != nil {
+		return err
+	}
+	attachmentsBytes, err := json.Marshal(attachments)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(attachmentsBytes)
+	return nil
+}
+
+// Upload streams every "file" part of a multipart/form-data body into the
+// attachment service, which enforces size, type and dimension limits per file.
+// The upload is all or nothing, when one part fails the attachments created
+// for the earlier ones are deleted again. Only the product owner may upload.
+func (h attachmentHandler) Upload(w http.ResponseWriter, request *http.Request) (err error) {
+	w.Header().Set("Content-Type", "application/json")
+	productId, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	userId, _, err := currentUser(request)
+	if err != nil {
+		return err
+	}
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return apperror.BadRequestError("expected multipart/form-data body")
+	}
+
+	uploaded := make([]*attachment.Attachment, 0)
+	defer func() {
+		if err != nil {
+			h.discard(request.Context(), uploaded, userId)
+		}
+	}()
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return apperror.BadRequestError("malformed multipart body")
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		created, err := h.service.Upload(request.Context(), productId, userId, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+		uploaded = append(uploaded, created)
+	}
+	if len(uploaded) == 0 {
+		return apperror.BadRequestError("no file part in request")
+	}
+
+	uploadedBytes, err := json.Marshal(uploaded)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(uploadedBytes)
+	return nil
+}
+
+// discard deletes the rows and blobs of attachments, detached from the request
+// so that a client disconnect does not leave them behind.
+func (h attachmentHandler) discard(ctx context.Context, attachments []*attachment.Attachment, userId int) {
+	logger := logging.FromContext(ctx)
+	ctx, cancel := context.WithTimeout(context.Background(), discardTimeout)
+	defer cancel()
+	for _, a := range attachments {
+		if err := h.service.Delete(ctx, a.ID, userId); err != nil {
+			logger.Warnf("failed to discard attachment %d: %v", a.ID, err)
+		}
+	}
+}
+
+func (h attachmentHandler) Delete(w http.ResponseWriter, request *http.Request) error {
+	id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	userId, _, err := currentUser(request)
+	if err != nil {
+		return err
+	}
+	if err := h.service.Delete(request.Context(), id, userId); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h attachmentHandler) Download(w http.ResponseWriter, request *http.Request) error {
+	query := request.URL.Query()
+	id, err := strconv.Atoi(query.Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return apperror.InvalidSignedURL
+	}
+	variant := query.Get("variant")
+	a, content, err := h.service.Open(request.Context(), id, variant, expires, query.Get("sig"))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	contentType := a.ContentType
+	disposition := "attachment"
+	if variant == attachment.VariantThumbnail {
+		contentType = "image/jpeg"
+	}
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, a.Filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		logging.FromContext(request.Context()).Warnf("failed to stream attachment %d: %v", id, err)
+	}
+	return nil
+}
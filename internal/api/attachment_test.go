@@ -0,0 +1,145 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/attachment"
+	"go.mod/internal/apps/product"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/blobstore"
+	"go.mod/pkg/blobstore/memory"
+	"go.mod/pkg/logging"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// attachments keeps attachment rows by id.
+type attachments struct {
+	rows   map[int]attachment.Attachment
+	nextID int
+}
+
+func (s *attachments) Create(_ context.Context, dto attachment.CreateAttachmentDTO) (*attachment.Attachment, error) {
+	s.nextID++
+	a := attachment.Attachment{ID: s.nextID, ProductId: dto.ProductId, Key: dto.Key, ThumbnailKey: dto.ThumbnailKey, Filename: dto.Filename, ContentType: dto.ContentType, Size: dto.Size}
+	s.rows[a.ID] = a
+	return &a, nil
+}
+
+func (s *attachments) FindOne(_ context.Context, id int) (*attachment.Attachment, error) {
+	a, ok := s.rows[id]
+	if !ok {
+		return nil, apperror.ErrorNotFound
+	}
+	return &a, nil
+}
+
+func (s *attachments) FindProductAttachments(_ context.Context, productId int) ([]attachment.Attachment, error) {
+	found := make([]attachment.Attachment, 0)
+	for _, a := range s.rows {
+		if a.ProductId == productId {
+			found = append(found, a)
+		}
+	}
+	return found, nil
+}
+
+func (s *attachments) Delete(_ context.Context, id int) error {
+	delete(s.rows, id)
+	return nil
+}
+
+// ownedProducts are all owned by user 1, only FindOneById is used.
+type ownedProducts struct {
+	product.Service
+}
+
+func (ownedProducts) FindOneById(_ context.Context, id int) (*product.Product, error) {
+	return &product.Product{ID: id, OwnerId: 1}, nil
+}
+
+// blobKeys records the keys held by the wrapped store.
+type blobKeys struct {
+	blobstore.BlobStore
+	keys map[string]bool
+}
+
+func (b *blobKeys) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	b.keys[key] = true
+	return b.BlobStore.Put(ctx, key, r, size, contentType)
+}
+
+func (b *blobKeys) Delete(ctx context.Context, key string) error {
+	delete(b.keys, key)
+	return b.BlobStore.Delete(ctx, key)
+}
+
+// multipartBody returns a body with one "file" part per file name and content.
+func multipartBody(t *testing.T, files ...string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for i := 0; i+1 < len(files); i += 2 {
+		part, err := writer.CreateFormFile("file", files[i])
+		require.NoError(t, err)
+		_, err = part.Write([]byte(files[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestUploadIsAllOrNothing(t *testing.T) {
+	storage := &attachments{rows: make(map[int]attachment.Attachment)}
+	blobs := &blobKeys{BlobStore: memory.NewBlobStore(), keys: make(map[string]bool)}
+	service := attachment.NewService(storage, ownedProducts{}, blobs, attachment.Options{
+		MaxSize: 1 << 20, MaxWidth: 100, MaxHeight: 100, URLTTL: time.Minute, SigningKey: []byte("test-signing-key"),
+	}, logging.GetLogger())
+	s := newServer(t, api.NewAttachmentHandler(logging.GetLogger(), service))
+
+	owner := bearer(t, 1, user.RoleUser)
+	uploadAs := func(token string, files ...string) *httptest.ResponseRecorder {
+		body, contentType := multipartBody(t, files...)
+		request := httptest.NewRequest(http.MethodPost, "/products/attachments/?id=1", body)
+		request.Header.Set("Content-Type", contentType)
+		if token != "" {
+			request.Header.Set("Authorization", token)
+		}
+		recorder := httptest.NewRecorder()
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	upload := func(files ...string) *httptest.ResponseRecorder {
+		return uploadAs(owner, files...)
+	}
+
+	requireProblem(t, uploadAs("", "a.txt", "first notes"), apperror.UnauthorizedError(""))
+	requireProblem(t, uploadAs(bearer(t, 2, user.RoleUser), "a.txt", "first notes"), apperror.ProductNotOwner)
+	assert.Empty(t, storage.rows)
+
+	requireProblem(t, upload("a.txt", "first notes", "b.txt", "second notes", "page.html", "<html><body>x</body></html>"), apperror.UnsupportedMediaType)
+	assert.Empty(t, storage.rows, "rows of the earlier files are deleted")
+	assert.Empty(t, blobs.keys, "blobs of the earlier files are deleted")
+
+	recorder := upload("a.txt", "first notes", "b.txt", "second notes")
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	var created []attachment.Attachment
+	decode(t, recorder, &created)
+	require.Len(t, created, 2)
+	assert.Len(t, storage.rows, 2)
+	assert.Len(t, blobs.keys, 2)
+
+	deleteTarget := "/products/attachments/id/?id=" + strconv.Itoa(created[0].ID)
+	requireProblem(t, s.do(http.MethodDelete, deleteTarget, ""), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodDelete, deleteTarget, "", "Authorization", bearer(t, 2, user.RoleUser)), apperror.ProductNotOwner)
+	assert.Equal(t, http.StatusNoContent, s.do(http.MethodDelete, deleteTarget, "", "Authorization", owner).Code)
+	assert.Len(t, storage.rows, 1)
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"go.mod/internal/apperror"
+	"go.mod/pkg/jwt"
+	"net/http"
+	"strconv"
+)
+
+// currentUser returns the id and role of the user authenticated by
+// jwt.Middleware, request parameters never name the acting user.
+func currentUser(request *http.Request) (id int, role string, err error) {
+	claims, ok := jwt.ClaimsFromContext(request.Context())
+	if !ok {
+		return 0, "", apperror.UnauthorizedError("unauthorized")
+	}
+	id, err = strconv.Atoi(claims.ID)
+	if err != nil {
+		return 0, "", apperror.UnauthorizedError("malformed token subject")
+	}
+	return id, claims.Role, nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"go.mod/internal"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/jwt"
+	"go.mod/pkg/logging"
+	"net/http"
+)
+
+const cachesUrl = "/admin/caches"
+
+type cacheHandler struct {
+	logger *logging.Logger
+	caches map[string]cache.Repository
+}
+
+// NewCacheHandler reports the Stats of caches by name and lets admins empty
+// them, entirely or by key prefix.
+func NewCacheHandler(logger *logging.Logger, caches map[string]cache.Repository) internal.Handler {
+	return &cacheHandler{
+		logger: logger,
+		caches: caches,
+	}
+}
+
+func (h cacheHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, cachesUrl, jwt.RequireRole(apperror.Middleware(h.GetStats), user.RoleAdmin))
+	router.HandlerFunc(http.MethodDelete, cachesUrl, jwt.RequireRole(apperror.Middleware(h.Clear), user.RoleAdmin))
+}
+
+func (h cacheHandler) GetStats(w http.ResponseWriter, request *http.Request) error {
+	return h.writeStats(w)
+}
+
+// Clear empties the cache given by the name query parameter, or only drops
+// the keys starting with prefix when it is set.
+func (h cacheHandler) Clear(w http.ResponseWriter, request *http.Request) error {
+	name := request.URL.Query().Get("name")
+	if name == "" {
+		return apperror.BadRequestError("name query parameter is required")
+	}
+	c, ok := h.caches[name]
+	if !ok {
+		return apperror.ErrorNotFound
+	}
+	logger := logging.FromContext(request.Context())
+	if prefix := request.URL.Query().Get("prefix"); prefix != "" {
+		deleted := c.DelPrefix([]byte(prefix))
+		logger.Warnf("deleted %d entries with prefix %q from cache %q", deleted, prefix, name)
+	} else {
+		c.Clear()
+		logger.Warnf("cleared cache %q", name)
+	}
+	return h.writeStats(w)
+}
+
+func (h cacheHandler) writeStats(w http.ResponseWriter) error {
+	stats := make(map[string]cache.Stats, len(h.caches))
+	for name, c := range h.caches {
+		stats[name] = c.Stats()
+	}
+	statsBytes, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(statsBytes)
+	return nil
+}
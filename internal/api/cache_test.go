@@ -0,0 +1,52 @@
+package api_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/cache/lru"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+func TestCachesRequireAdmin(t *testing.T) {
+	tokens := lru.NewCacheRepo(lru.Options{MaxEntries: 16})
+	require.NoError(t, tokens.Set([]byte("token"), []byte("user"), 0))
+	s := newServer(t, api.NewCacheHandler(logging.GetLogger(), map[string]cache.Repository{"refresh_tokens": tokens}))
+
+	requireProblem(t, s.do(http.MethodDelete, "/admin/caches?name=refresh_tokens", ""), apperror.UnauthorizedError(""))
+	for _, role := range []string{user.RoleUser, user.RoleModerator} {
+		recorder := s.do(http.MethodDelete, "/admin/caches?name=refresh_tokens", "", "Authorization", bearer(t, 1, role))
+		requireProblem(t, recorder, apperror.ForbiddenError(""))
+		recorder = s.do(http.MethodGet, "/admin/caches", "", "Authorization", bearer(t, 1, role))
+		requireProblem(t, recorder, apperror.ForbiddenError(""))
+	}
+	_, err := tokens.Get([]byte("token"))
+	require.NoError(t, err)
+}
+
+func TestClearCache(t *testing.T) {
+	entities := lru.NewCacheRepo(lru.Options{MaxEntries: 16})
+	require.NoError(t, entities.Set([]byte("product:1"), []byte("1"), 0))
+	require.NoError(t, entities.Set([]byte("product:2"), []byte("2"), 0))
+	require.NoError(t, entities.Set([]byte("category:all"), []byte("[]"), 0))
+	s := newServer(t, api.NewCacheHandler(logging.GetLogger(), map[string]cache.Repository{"entities": entities}))
+	admin := bearer(t, 1, user.RoleAdmin)
+
+	requireProblem(t, s.do(http.MethodDelete, "/admin/caches", "", "Authorization", admin), apperror.BadRequestError(""))
+	requireProblem(t, s.do(http.MethodDelete, "/admin/caches?name=sessions", "", "Authorization", admin), apperror.ErrorNotFound)
+
+	recorder := s.do(http.MethodDelete, "/admin/caches?name=entities&prefix=product:", "", "Authorization", admin)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var stats map[string]cache.Stats
+	decode(t, recorder, &stats)
+	require.Equal(t, int64(1), stats["entities"].Entries)
+
+	recorder = s.do(http.MethodDelete, "/admin/caches?name=entities", "", "Authorization", admin)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	decode(t, recorder, &stats)
+	require.Zero(t, stats["entities"].Entries)
+}
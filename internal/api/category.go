@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"github.com/julienschmidt/httprouter"
 	"go.mod/internal"
@@ -39,13 +38,13 @@ func (h categoryHandler) Register(router *httprouter.Router) {
 }
 
 func (h categoryHandler) GetList(writer http.ResponseWriter, request *http.Request) error {
-	all, err := h.service.FindAll(context.TODO())
+	all, err := h.service.FindAll(request.Context())
 	if err != nil {
 		return err
 	}
 	allBytes, err := json.Marshal(all)
-	writer.Write(allBytes)
 	writer.WriteHeader(http.StatusOK)
+	writer.Write(allBytes)
 	return nil
 }
 
@@ -55,7 +54,7 @@ func (h categoryHandler) GetOneById(writer http.ResponseWriter, request *http.Re
 	if err != nil {
 		return apperror.IdQueryParamError
 	}
-	categoryObj, err := h.service.FindOneById(context.TODO(), idInt)
+	categoryObj, err := h.service.FindOneById(request.Context(), idInt)
 	if err != nil {
 		return err
 	}
@@ -63,14 +62,14 @@ func (h categoryHandler) GetOneById(writer http.ResponseWriter, request *http.Re
 	if err != nil {
 		return err
 	}
-	writer.Write(categoryObjBytes)
 	writer.WriteHeader(http.StatusOK)
+	writer.Write(categoryObjBytes)
 	return nil
 }
 
 func (h categoryHandler) GetOneByTitle(writer http.ResponseWriter, request *http.Request) error {
 	title := request.URL.Query().Get("title")
-	byTitle, err := h.service.FindOneByTitle(context.TODO(), title)
+	byTitle, err := h.service.FindOneByTitle(request.Context(), title)
 	if err != nil {
 		return err
 	}
@@ -78,8 +77,8 @@ func (h categoryHandler) GetOneByTitle(writer http.ResponseWriter, request *http
 	if err != nil {
 		return err
 	}
-	writer.Write(byTitleBytes)
 	writer.WriteHeader(http.StatusOK)
+	writer.Write(byTitleBytes)
 	return nil
 }
 
@@ -87,10 +86,9 @@ func (h categoryHandler) Create(writer http.ResponseWriter, request *http.Reques
 	writer.Header().Set("Content-Type", "application/json")
 	var categoryDTO category.CreateUpdateCategory
 	if err := json.NewDecoder(request.Body).Decode(&categoryDTO); err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
 		return apperror.BadRequestError("can't decode")
 	}
-	categoryObj, err := h.service.Create(context.TODO(), categoryDTO)
+	categoryObj, err := h.service.Create(request.Context(), categoryDTO)
 	if err != nil {
 		return err
 	}
@@ -98,7 +96,7 @@ func (h categoryHandler) Create(writer http.ResponseWriter, request *http.Reques
 	if err != nil {
 		return err
 	}
-	writer.Write(categoryObjBytes)
 	writer.WriteHeader(http.StatusCreated)
+	writer.Write(categoryObjBytes)
 	return nil
 }
@@ -0,0 +1,56 @@
+package api_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/category"
+	"go.mod/internal/apps/category/memory"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+func newCategoryServer(t *testing.T) *server {
+	logger := logging.GetLogger()
+	service := category.NewService(memory.NewCategoryRepository(), logger)
+	return newServer(t, api.NewCategoryHandler(logger, service))
+}
+
+func TestCategoryHandler(t *testing.T) {
+	s := newCategoryServer(t)
+
+	response := s.do(http.MethodPost, "/categories/", `{"title": "books"}`)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	var created category.Category
+	decode(t, response, &created)
+	assert.NotZero(t, created.Id)
+	assert.Equal(t, "books", created.Title)
+
+	response = s.do(http.MethodPost, "/categories/", `{"title": "books"}`)
+	requireProblem(t, response, apperror.CategoryTileAlreadyExist)
+
+	response = s.do(http.MethodGet, "/categories/id?id=1", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	var found category.Category
+	decode(t, response, &found)
+	assert.Equal(t, created, found)
+
+	response = s.do(http.MethodGet, "/categories/title?title=books", "")
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var all []category.Category
+	decode(t, s.do(http.MethodGet, "/categories/", ""), &all)
+	assert.Equal(t, []category.Category{created}, all)
+}
+
+func TestCategoryHandlerErrors(t *testing.T) {
+	s := newCategoryServer(t)
+
+	requireProblem(t, s.do(http.MethodGet, "/categories/id?id=42", ""), apperror.ErrorNotFound)
+	requireProblem(t, s.do(http.MethodGet, "/categories/id?id=one", ""), apperror.IdQueryParamError)
+	requireProblem(t, s.do(http.MethodGet, "/categories/title?title=none", ""), apperror.ErrorNotFound)
+	response := s.do(http.MethodPost, "/categories/", `{"title":`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"go.mod/internal"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/comment"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/jwt"
+	"go.mod/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+const (
+	commentsUrl          = "/comments/"
+	commentUrl           = "/comments/id/"
+	commentModerationUrl = "/comments/moderation/"
+)
+
+type commentHandler struct {
+	logger  *logging.Logger
+	service comment.Service
+}
+
+func NewCommentHandler(logger *logging.Logger, s comment.Service) internal.Handler {
+	return &commentHandler{
+		logger:  logger,
+		service: s,
+	}
+}
+
+func (h commentHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, commentsUrl, apperror.Middleware(h.GetThread))
+	router.HandlerFunc(http.MethodPost, commentsUrl, jwt.Middleware(apperror.Middleware(h.Create)))
+	router.HandlerFunc(http.MethodGet, commentUrl, jwt.Optional(apperror.Middleware(h.Get)))
+	router.HandlerFunc(http.MethodPut, commentUrl, jwt.Middleware(apperror.Middleware(h.Update)))
+	router.HandlerFunc(http.MethodDelete, commentUrl, jwt.Middleware(apperror.Middleware(h.Delete)))
+	router.HandlerFunc(http.MethodGet, commentModerationUrl, jwt.RequireRole(apperror.Middleware(h.GetPending), user.RoleModerator, user.RoleAdmin))
+	router.HandlerFunc(http.MethodPut, commentModerationUrl, jwt.RequireRole(apperror.Middleware(h.Moderate), user.RoleModerator, user.RoleAdmin))
+}
+
+// actor returns the authenticated user as the one changing a comment.
+func actor(request *http.Request) (comment.Actor, error) {
+	id, role, err := currentUser(request)
+	if err != nil {
+		return comment.Actor{}, err
+	}
+	return comment.Actor{UserId: id, Moderator: role == user.RoleModerator || role == user.RoleAdmin}, nil
+}
+
+// viewer returns the user reading a comment, the zero Actor when anonymous.
+func viewer(request *http.Request) (comment.Actor, error) {
+	if _, ok := jwt.ClaimsFromContext(request.Context()); !ok {
+		return comment.Actor{}, nil
+	}
+	return actor(request)
+}
+
+func (h commentHandler) GetThread(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	productId, err := strconv.Atoi(request.URL.Query().Get("product_id"))
+	if err != nil {
+		return apperror.BadRequestError("param product_id must be number")
+	}
+	thread, err := h.service.FindProductThread(request.Context(), productId)
+	if err != nil {
+		return err
+	}
+	threadBytes, err := json.Marshal(thread)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(threadBytes)
+	return nil
+}
+
+func (h commentHandler) Get(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	reader, err := viewer(request)
+	if err != nil {
+		return err
+	}
+	commentObj, err := h.service.FindOneById(request.Context(), id, reader)
+	if err != nil {
+		return err
+	}
+	commentBytes, err := json.Marshal(commentObj)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(commentBytes)
+	return nil
+}
+
+func (h commentHandler) Create(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	authorId, _, err := currentUser(request)
+	if err != nil {
+		return err
+	}
+	var createComment comment.CreateCommentDTO
+	if err := json.NewDecoder(request.Body).Decode(&createComment); err != nil {
+		return apperror.BadRequestError("can't decode")
+	}
+	createComment.AuthorId = authorId
+	created, err := h.service.Create(request.Context(), createComment)
+	if err != nil {
+		return err
+	}
+	createdBytes, err := json.Marshal(created)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(createdBytes)
+	return nil
+}
+
+func (h commentHandler) Update(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	updater, err := actor(request)
+	if err != nil {
+		return err
+	}
+	commentObj, err := h.service.FindOneById(request.Context(), id, updater)
+	if err != nil {
+		return err
+	}
+	var updateComment comment.UpdateCommentDTO
+	if err := json.NewDecoder(request.Body).Decode(&updateComment); err != nil {
+		return apperror.BadRequestError("can't decode")
+	}
+	updated, err := h.service.Update(request.Context(), *commentObj, updateComment, updater)
+	if err != nil {
+		return err
+	}
+	updatedBytes, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(updatedBytes)
+	return nil
+}
+
+func (h commentHandler) Delete(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	deleter, err := actor(request)
+	if err != nil {
+		return err
+	}
+	if err := h.service.Delete(request.Context(), id, deleter); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h commentHandler) GetPending(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	pending, err := h.service.FindPending(request.Context())
+	if err != nil {
+		return err
+	}
+	pendingBytes, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(pendingBytes)
+	return nil
+}
+
+func (h commentHandler) Moderate(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	var moderateComment comment.ModerateCommentDTO
+	if err := json.NewDecoder(request.Body).Decode(&moderateComment); err != nil {
+		return apperror.BadRequestError("can't decode")
+	}
+	moderated, err := h.service.Moderate(request.Context(), id, moderateComment.Status)
+	if err != nil {
+		return err
+	}
+	moderatedBytes, err := json.Marshal(moderated)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(moderatedBytes)
+	return nil
+}
@@ -0,0 +1,25 @@
+package api_test
+
+import (
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+// The service is nil, every request below is rejected before reaching it.
+func TestCommentRoutesRequireAuthentication(t *testing.T) {
+	s := newServer(t, api.NewCommentHandler(logging.GetLogger(), nil))
+
+	requireProblem(t, s.do(http.MethodPost, "/comments/", `{"product_id":1,"author_id":2,"body":"hi"}`), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodPut, "/comments/id/?id=1", `{"body":"hi"}`), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodDelete, "/comments/id/?id=1", ""), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodGet, "/comments/moderation/", ""), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodGet, "/comments/id/?id=1", "", "Authorization", "Bearer forged"), apperror.UnauthorizedError(""))
+
+	token := bearer(t, 1, user.RoleUser)
+	requireProblem(t, s.do(http.MethodGet, "/comments/moderation/", "", "Authorization", token), apperror.ForbiddenError(""))
+	requireProblem(t, s.do(http.MethodPut, "/comments/moderation/?id=1", `{"status":"approved"}`, "Authorization", token), apperror.ForbiddenError(""))
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// entityTag renders the row version of a resource as a strong ETag.
+func entityTag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// productTag tags a product representation. Reactions and bookmarks change
+// without a version bump, so a hash of the counters follows the version; a
+// product nobody reacted to or bookmarked keeps the plain version tag.
+func productTag(p *product.Product) string {
+	if len(p.Reactions) == 0 && p.Bookmarks == 0 {
+		return entityTag(p.Version)
+	}
+	kinds := make([]string, 0, len(p.Reactions))
+	for kind := range p.Reactions {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	h := fnv.New64a()
+	for _, kind := range kinds {
+		fmt.Fprintf(h, "%s=%d;", kind, p.Reactions[kind])
+	}
+	fmt.Fprintf(h, "bookmarks=%d", p.Bookmarks)
+	return fmt.Sprintf(`"%d-%x"`, p.Version, h.Sum64())
+}
+
+// matchesEntityTag reports whether a If-Match / If-None-Match header value
+// lists the tag. The weak comparison of If-None-Match compares validators by
+// their opaque part only, the strong one of If-Match never matches a weak one.
+func matchesEntityTag(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag of a GET response and answers 304 when the client
+// already holds the representation tagged tag. It returns true if the response
+// is done.
+func notModified(w http.ResponseWriter, request *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+	if header := request.Header.Get("If-None-Match"); header != "" && matchesEntityTag(header, tag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// checkIfMatch requires an If-Match header naming the current tag, so a client
+// can only update what it has actually seen.
+func checkIfMatch(request *http.Request, tag string) error {
+	header := request.Header.Get("If-Match")
+	if header == "" {
+		return apperror.PreconditionRequired
+	}
+	if !matchesEntityTag(header, tag, false) {
+		return apperror.PreconditionFailed
+	}
+	return nil
+}
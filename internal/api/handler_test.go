@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/internal/config"
+	"go.mod/pkg/cache/lru"
+	"go.mod/pkg/jwt"
+	"go.mod/pkg/logging"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// server routes requests to the handlers under test.
+type server struct {
+	t      *testing.T
+	router *httprouter.Router
+}
+
+func newServer(t *testing.T, handlers ...internal.Handler) *server {
+	router := httprouter.New()
+	for _, h := range handlers {
+		h.Register(router)
+	}
+	return &server{t: t, router: router}
+}
+
+// do serves a request with an optional JSON body and header pairs.
+func (s *server) do(method, target, body string, header ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request := httptest.NewRequest(method, target, reader)
+	for i := 0; i+1 < len(header); i += 2 {
+		request.Header.Set(header[i], header[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// decode unmarshals the response body into v.
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), v), recorder.Body.String())
+}
+
+// requireProblem checks that the response is the problem document of want.
+func requireProblem(t *testing.T, recorder *httptest.ResponseRecorder, want *apperror.AppError) {
+	t.Helper()
+	require.Equal(t, apperror.ProblemContentType, recorder.Header().Get("Content-Type"), recorder.Body.String())
+	var problem apperror.Problem
+	decode(t, recorder, &problem)
+	require.Equal(t, want.Code, problem.Code, recorder.Body.String())
+	require.Equal(t, want.Status, recorder.Code)
+	require.Equal(t, want.Status, problem.Status)
+}
+
+// bearer returns an Authorization header value for a user with role, signed
+// with the secret of the repository configuration.
+func bearer(t *testing.T, id int, role string) string {
+	t.Helper()
+	_, err := config.Load("../../config.yaml", "")
+	require.NoError(t, err)
+	helper := jwt.NewHelper(lru.NewCacheRepo(lru.Options{MaxEntries: 16}), logging.GetLogger())
+	tokens, err := helper.GenerateAccessToken(user.User{ID: id, Username: "user", Role: role})
+	require.NoError(t, err)
+	var body struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(tokens, &body))
+	return "Bearer " + body.Token
+}
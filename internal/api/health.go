@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"go.mod/internal"
+	"go.mod/pkg/logging"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	livenessUrl  = "/healthz"
+	readinessUrl = "/readyz"
+
+	healthCheckTimeout = 2 * time.Second
+)
+
+// HealthCheck reports whether a dependency can serve requests.
+type HealthCheck func(ctx context.Context) error
+
+type HealthHandler interface {
+	internal.Handler
+	// Drain makes the readiness probe fail so load balancers stop routing
+	// new requests while in-flight ones complete.
+	Drain()
+}
+
+type dependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies"`
+}
+
+type healthHandler struct {
+	logger   *logging.Logger
+	checks   map[string]HealthCheck
+	draining atomic.Bool
+}
+
+func NewHealthHandler(logger *logging.Logger, checks map[string]HealthCheck) HealthHandler {
+	return &healthHandler{
+		logger: logger,
+		checks: checks,
+	}
+}
+
+func (h *healthHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, livenessUrl, h.Live)
+	router.HandlerFunc(http.MethodGet, readinessUrl, h.Ready)
+}
+
+func (h *healthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Live only tells that the process serves HTTP, dependencies are left to
+// the readiness probe so an outage of Postgres does not restart the app.
+func (h *healthHandler) Live(w http.ResponseWriter, request *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+func (h *healthHandler) Ready(w http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithTimeout(request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	result := readiness{Status: "ok", Dependencies: h.run(ctx)}
+	status := http.StatusOK
+	for _, dependency := range result.Dependencies {
+		if dependency.Status != "up" {
+			result.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if h.draining.Load() {
+		result.Status = "draining"
+		status = http.StatusServiceUnavailable
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// run checks every dependency concurrently so one slow dependency does not
+// hide the state of the others.
+func (h *healthHandler) run(ctx context.Context) map[string]dependencyStatus {
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statuses := make([]dependencyStatus, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			start := time.Now()
+			err := h.checks[name](ctx)
+			statuses[i] = dependencyStatus{
+				Status:    "up",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			// the error stays in the logs, the probe is not authenticated
+			if err != nil {
+				statuses[i].Status = "down"
+				logging.FromContext(ctx).Warnf("readiness check %s failed: %v", name, err)
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	result := make(map[string]dependencyStatus, len(names))
+	for i, name := range names {
+		result[name] = statuses[i]
+	}
+	return result
+}
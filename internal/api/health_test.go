@@ -0,0 +1,53 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/api"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	down := true
+	health := api.NewHealthHandler(logging.GetLogger(), map[string]api.HealthCheck{
+		"postgres": func(ctx context.Context) error { return nil },
+		"entities_cache": func(ctx context.Context) error {
+			if down {
+				return errors.New("dial tcp 10.0.3.7:5432: connection refused")
+			}
+			return nil
+		},
+	})
+	s := newServer(t, health)
+
+	var result struct {
+		Status       string                            `json:"status"`
+		Dependencies map[string]map[string]interface{} `json:"dependencies"`
+	}
+	recorder := s.do(http.MethodGet, "/readyz", "")
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "10.0.3.7", "check errors are not exposed")
+	decode(t, recorder, &result)
+	assert.Equal(t, "unavailable", result.Status)
+	assert.Equal(t, "down", result.Dependencies["entities_cache"]["status"])
+	assert.Equal(t, "up", result.Dependencies["postgres"]["status"])
+	assert.NotContains(t, result.Dependencies["entities_cache"], "error")
+
+	down = false
+	recorder = s.do(http.MethodGet, "/readyz", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	decode(t, recorder, &result)
+	assert.Equal(t, "ok", result.Status)
+
+	health.Drain()
+	recorder = s.do(http.MethodGet, "/readyz", "")
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	decode(t, recorder, &result)
+	assert.Equal(t, "draining", result.Status)
+
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/healthz", "").Code, "liveness ignores draining")
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"go.mod/internal"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/jwt"
+	"go.mod/pkg/logging"
+	"net/http"
+)
+
+const logLevelsUrl = "/admin/log-levels"
+
+type logLevelsResponse struct {
+	logging.LevelConfig
+	SampledOut uint64 `json:"sampled_out"`
+}
+
+type updateLogLevel struct {
+	// Package is relative to the module, e.g. internal/apps/product/db. An
+	// empty package changes the default level.
+	Package string `json:"package"`
+	// Level is one of trace, debug, info, warning, error. An empty level
+	// removes the package override.
+	Level string `json:"level"`
+}
+
+type loggingHandler struct {
+	logger *logging.Logger
+}
+
+func NewLoggingHandler(logger *logging.Logger) internal.Handler {
+	return &loggingHandler{
+		logger: logger,
+	}
+}
+
+func (h loggingHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, logLevelsUrl, jwt.RequireRole(apperror.Middleware(h.GetLevels), user.RoleAdmin))
+	router.HandlerFunc(http.MethodPut, logLevelsUrl, jwt.RequireRole(apperror.Middleware(h.SetLevel), user.RoleAdmin))
+}
+
+func (h loggingHandler) GetLevels(w http.ResponseWriter, request *http.Request) error {
+	return h.writeLevels(w)
+}
+
+func (h loggingHandler) SetLevel(w http.ResponseWriter, request *http.Request) error {
+	var update updateLogLevel
+	if err := json.NewDecoder(request.Body).Decode(&update); err != nil {
+		return apperror.BadRequestError("can't decode")
+	}
+	var err error
+	if update.Package == "" && update.Level == "" {
+		return apperror.BadRequestError("package or level is required")
+	}
+	if update.Package == "" {
+		err = logging.SetLevel(update.Level)
+	} else {
+		err = logging.SetPackageLevel(update.Package, update.Level)
+	}
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	logging.FromContext(request.Context()).Warnf("log level of %q set to %q", update.Package, update.Level)
+	return h.writeLevels(w)
+}
+
+func (h loggingHandler) writeLevels(w http.ResponseWriter) error {
+	levelsBytes, err := json.Marshal(logLevelsResponse{LevelConfig: logging.Levels(), SampledOut: logging.Dropped()})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(levelsBytes)
+	return nil
+}
@@ -0,0 +1,48 @@
+package api_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+func TestLogLevelsRequireAdmin(t *testing.T) {
+	s := newServer(t, api.NewLoggingHandler(logging.GetLogger()))
+
+	requireProblem(t, s.do(http.MethodGet, "/admin/log-levels", ""), apperror.UnauthorizedError(""))
+	for _, role := range []string{user.RoleUser, user.RoleModerator, ""} {
+		recorder := s.do(http.MethodPut, "/admin/log-levels", `{"level":"trace"}`, "Authorization", bearer(t, 1, role))
+		requireProblem(t, recorder, apperror.ForbiddenError(""))
+	}
+	require.NotEqual(t, "trace", logging.Levels().Default)
+}
+
+func TestSetLogLevel(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, logging.SetLevels("info", nil)) })
+	s := newServer(t, api.NewLoggingHandler(logging.GetLogger()))
+	admin := bearer(t, 1, user.RoleAdmin)
+
+	recorder := s.do(http.MethodPut, "/admin/log-levels", `{"package":"internal/apps/product","level":"debug"}`, "Authorization", admin)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var levels logging.LevelConfig
+	decode(t, recorder, &levels)
+	require.Equal(t, "debug", levels.Packages["internal/apps/product"])
+
+	recorder = s.do(http.MethodPut, "/admin/log-levels", `{"package":"internal/apps/product"}`, "Authorization", admin)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	levels = logging.LevelConfig{}
+	decode(t, recorder, &levels)
+	require.NotContains(t, levels.Packages, "internal/apps/product")
+
+	requireProblem(t, s.do(http.MethodPut, "/admin/log-levels", `{"level":"loud"}`, "Authorization", admin), apperror.BadRequestError(""))
+	requireProblem(t, s.do(http.MethodPut, "/admin/log-levels", `{}`, "Authorization", admin), apperror.BadRequestError(""))
+
+	recorder = s.do(http.MethodGet, "/admin/log-levels", "", "Authorization", admin)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	decode(t, recorder, &levels)
+	require.Equal(t, "info", levels.Default)
+}
@@ -1,20 +1,23 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"github.com/julienschmidt/httprouter"
 	"go.mod/internal"
 	"go.mod/internal/apperror"
 	"go.mod/internal/apps/product"
+	"go.mod/pkg/jwt"
 	"go.mod/pkg/logging"
 	"net/http"
 	"strconv"
 )
 
 const (
-	postsUrl = "/products/"
-	postUrl  = "/products/id/"
+	postsUrl         = "/products/"
+	postUrl          = "/products/id/"
+	postRevisionsUrl = "/products/revisions/"
+	postDiffUrl      = "/products/revisions/diff/"
+	postRollbackUrl  = "/products/revisions/rollback/"
 )
 
 type postHandler struct {
@@ -33,18 +36,20 @@ func (h postHandler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, postsUrl, apperror.Middleware(h.GetList))
 	router.HandlerFunc(http.MethodGet, postUrl, apperror.Middleware(h.Get))
 	router.HandlerFunc(http.MethodPost, postsUrl, apperror.Middleware(h.Create))
-	router.HandlerFunc(http.MethodPut, postUrl, apperror.Middleware(h.Update))
+	router.HandlerFunc(http.MethodPut, postUrl, jwt.Middleware(apperror.Middleware(h.Update)))
 	router.HandlerFunc(http.MethodDelete, postUrl, apperror.Middleware(h.Delete))
+	router.HandlerFunc(http.MethodGet, postRevisionsUrl, apperror.Middleware(h.GetRevisions))
+	router.HandlerFunc(http.MethodGet, postDiffUrl, apperror.Middleware(h.GetDiff))
+	router.HandlerFunc(http.MethodPost, postRollbackUrl, jwt.Middleware(apperror.Middleware(h.Rollback)))
 }
 
 func (h postHandler) GetList(w http.ResponseWriter, request *http.Request) error {
-	posts, err := h.service.FindAll(context.TODO())
+	posts, err := h.service.FindAll(request.Context())
 	if err != nil {
 		return err
 	}
 	postsBytes, err := json.Marshal(posts)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
@@ -56,15 +61,17 @@ func (h postHandler) Get(w http.ResponseWriter, request *http.Request) error {
 	id := request.URL.Query().Get("id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		return err
+		return apperror.IdQueryParamError
 	}
-	post, err := h.service.FindOneById(context.TODO(), idInt)
+	post, err := h.service.FindOneById(request.Context(), idInt)
 	if err != nil {
 		return err
 	}
+	if notModified(w, request, productTag(post)) {
+		return nil
+	}
 	postBytes, err := json.Marshal(post)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	w.Write(postBytes)
@@ -77,7 +84,7 @@ func (h postHandler) Create(w http.ResponseWriter, request *http.Request) error
 	if err := json.NewDecoder(request.Body).Decode(&CreatePostDTO); err != nil {
 		return apperror.BadRequestError("can't decode")
 	}
-	createdPost, err := h.service.Create(context.TODO(), CreatePostDTO)
+	createdPost, err := h.service.Create(request.Context(), CreatePostDTO)
 	if err != nil {
 		return err
 	}
@@ -97,24 +104,31 @@ func (h postHandler) Update(w http.ResponseWriter, request *http.Request) error
 	if err != nil {
 		return apperror.IdQueryParamError
 	}
-	postObj, err := h.service.FindOneById(context.TODO(), postIdInt)
+	authorId, _, err := currentUser(request)
 	if err != nil {
 		return err
 	}
+	postObj, err := h.service.FindOneById(request.Context(), postIdInt)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(request, productTag(postObj)); err != nil {
+		return err
+	}
 	var updatePost product.UpdateProductDTO
 	if err := json.NewDecoder(request.Body).Decode(&updatePost); err != nil {
-		h.logger.Debug(err)
+		logging.FromContext(request.Context()).Debug(err)
 		return apperror.BadRequestError("can't decode")
 	}
-	updatedPostObj, err := h.service.Update(context.TODO(), postObj, updatePost)
+	updatedPostObj, err := h.service.Update(request.Context(), postObj, updatePost, authorId)
 	if err != nil {
 		return err
 	}
 	updatedPostObjBytes, err := json.Marshal(updatedPostObj)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
+	w.Header().Set("ETag", productTag(updatedPostObj))
 	w.WriteHeader(http.StatusOK)
 	w.Write(updatedPostObjBytes)
 	return nil
@@ -127,12 +141,92 @@ func (h postHandler) Delete(w http.ResponseWriter, request *http.Request) error
 	if err != nil {
 		return apperror.IdQueryParamError
 	}
-	err = h.service.Delete(context.TODO(), postIdInt)
+	err = h.service.Delete(request.Context(), postIdInt)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
+	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("status: Deleted"))
+	return nil
+}
+
+func (h postHandler) GetRevisions(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	postId, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	revisions, err := h.service.FindRevisions(request.Context(), postId)
+	if err != nil {
+		return err
+	}
+	revisionsBytes, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(revisionsBytes)
+	return nil
+}
+
+func (h postHandler) GetDiff(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	postId, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	from, err := intQueryParam(request, "from")
+	if err != nil {
+		return err
+	}
+	to, err := intQueryParam(request, "to")
+	if err != nil {
+		return err
+	}
+	revisionDiff, err := h.service.Diff(request.Context(), postId, from, to)
+	if err != nil {
+		return err
+	}
+	revisionDiffBytes, err := json.Marshal(revisionDiff)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(revisionDiffBytes)
+	return nil
+}
+
+func (h postHandler) Rollback(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	postId, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		return apperror.IdQueryParamError
+	}
+	version, err := intQueryParam(request, "version")
+	if err != nil {
+		return err
+	}
+	authorId, _, err := currentUser(request)
+	if err != nil {
+		return err
+	}
+	postObj, err := h.service.FindOneById(request.Context(), postId)
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(request, productTag(postObj)); err != nil {
+		return err
+	}
+	restored, err := h.service.Rollback(request.Context(), postObj, version, authorId)
+	if err != nil {
+		return err
+	}
+	restoredBytes, err := json.Marshal(restored)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", productTag(restored))
 	w.WriteHeader(http.StatusOK)
+	w.Write(restoredBytes)
 	return nil
 }
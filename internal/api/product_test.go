@@ -0,0 +1,198 @@
+package api_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"go.mod/internal/apps/product/memory"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+// noTx runs the function without a transaction, the in-memory storage
+// applies every change on its own.
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newProductServer(t *testing.T) *server {
+	logger := logging.GetLogger()
+	service := product.NewService(memory.NewProductRepository(), noTx{}, logger)
+	return newServer(t, api.NewPostHandler(logger, service))
+}
+
+func TestProductHandler(t *testing.T) {
+	s := newProductServer(t)
+
+	response := s.do(http.MethodPost, "/products/", `{"title": "lamp", "description": "bright", "owner_id": 1}`)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	var created product.Product
+	decode(t, response, &created)
+	assert.Equal(t, "lamp", created.Title)
+	assert.Equal(t, 1, created.Version)
+
+	requireProblem(t, s.do(http.MethodPost, "/products/", `{"title": "lamp", "description": "x", "owner_id": 2}`), apperror.ProductTitleAlreadyExist)
+
+	response = s.do(http.MethodGet, "/products/id/?id=1", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `"1"`, response.Header().Get("ETag"))
+
+	response = s.do(http.MethodGet, "/products/id/?id=1", "", "If-None-Match", `"1"`)
+	assert.Equal(t, http.StatusNotModified, response.Code)
+
+	var all []product.Product
+	decode(t, s.do(http.MethodGet, "/products/", ""), &all)
+	require.Len(t, all, 1)
+	assert.Equal(t, created.ID, all[0].ID)
+}
+
+func TestProductHandlerUpdate(t *testing.T) {
+	s := newProductServer(t)
+	s.do(http.MethodPost, "/products/", `{"title": "lamp", "description": "bright", "owner_id": 1}`)
+	update := `{"title": "desk lamp", "description": "brighter", "author_id": 9}`
+	token := bearer(t, 5, user.RoleUser)
+
+	requireProblem(t, s.do(http.MethodPut, "/products/id/?id=1", update, "If-Match", `"1"`), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodPut, "/products/id/?id=1", update, "Authorization", token), apperror.PreconditionRequired)
+	requireProblem(t, s.do(http.MethodPut, "/products/id/?id=1", update, "If-Match", `"2"`, "Authorization", token), apperror.PreconditionFailed)
+
+	response := s.do(http.MethodPut, "/products/id/?id=1", update, "If-Match", `"1"`, "Authorization", token)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+	var updated product.Product
+	decode(t, response, &updated)
+	assert.Equal(t, "desk lamp", updated.Title)
+
+	var revisions []product.Revision
+	decode(t, s.do(http.MethodGet, "/products/revisions/?id=1", ""), &revisions)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Version)
+	assert.Equal(t, 5, revisions[0].AuthorId, "the author is the authenticated user, not the body")
+
+	var diff product.RevisionDiff
+	decode(t, s.do(http.MethodGet, "/products/revisions/diff/?id=1&from=1&to=2", ""), &diff)
+	assert.Equal(t, 1, diff.From)
+	assert.NotEmpty(t, diff.Title)
+
+	rollback := "/products/revisions/rollback/?id=1&version=1"
+	requireProblem(t, s.do(http.MethodPost, rollback, "", "If-Match", `"2"`), apperror.UnauthorizedError(""))
+	response = s.do(http.MethodPost, rollback+"&author_id=1", "", "If-Match", `"2"`, "Authorization", bearer(t, 7, user.RoleUser))
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var restored product.Product
+	decode(t, response, &restored)
+	assert.Equal(t, "lamp", restored.Title)
+	assert.Equal(t, 3, restored.Version)
+	assert.Equal(t, `"3"`, response.Header().Get("ETag"))
+
+	decode(t, s.do(http.MethodGet, "/products/revisions/?id=1", ""), &revisions)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, 7, revisions[0].AuthorId, "the author is the authenticated user")
+	require.NotNil(t, revisions[0].RollbackOf)
+	assert.Equal(t, 1, *revisions[0].RollbackOf)
+}
+
+func TestProductHandlerPreconditions(t *testing.T) {
+	s := newProductServer(t)
+	s.do(http.MethodPost, "/products/", `{"title": "lamp", "description": "bright", "owner_id": 1}`)
+	update := `{"title": "lamp", "description": "bright"}`
+	rollback := "/products/revisions/rollback/?id=1&version=1"
+	token := bearer(t, 1, user.RoleUser)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header []string
+		status int
+	}{
+		{"current version", http.MethodGet, "/products/id/?id=1", "", []string{"If-None-Match", `"1"`}, http.StatusNotModified},
+		{"weak current version", http.MethodGet, "/products/id/?id=1", "", []string{"If-None-Match", `W/"1"`}, http.StatusNotModified},
+		{"one of several", http.MethodGet, "/products/id/?id=1", "", []string{"If-None-Match", `"7", "1"`}, http.StatusNotModified},
+		{"any version", http.MethodGet, "/products/id/?id=1", "", []string{"If-None-Match", `*`}, http.StatusNotModified},
+		{"stale version", http.MethodGet, "/products/id/?id=1", "", []string{"If-None-Match", `"0"`}, http.StatusOK},
+		{"update without If-Match", http.MethodPut, "/products/id/?id=1", update, []string{"Authorization", token}, http.StatusPreconditionRequired},
+		{"update with stale version", http.MethodPut, "/products/id/?id=1", update, []string{"If-Match", `"2"`, "Authorization", token}, http.StatusPreconditionFailed},
+		{"update with weak version", http.MethodPut, "/products/id/?id=1", update, []string{"If-Match", `W/"1"`, "Authorization", token}, http.StatusPreconditionFailed},
+		{"rollback without If-Match", http.MethodPost, rollback, "", []string{"Authorization", token}, http.StatusPreconditionRequired},
+		{"rollback with stale version", http.MethodPost, rollback, "", []string{"If-Match", `"2"`, "Authorization", token}, http.StatusPreconditionFailed},
+		{"rollback with weak version", http.MethodPost, rollback, "", []string{"If-Match", `W/"1"`, "Authorization", token}, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := s.do(tt.method, tt.target, tt.body, tt.header...)
+			assert.Equal(t, tt.status, response.Code, response.Body.String())
+			if tt.status == http.StatusNotModified {
+				assert.Empty(t, response.Body.String())
+			}
+		})
+	}
+
+	var current product.Product
+	decode(t, s.do(http.MethodGet, "/products/id/?id=1", ""), &current)
+	assert.Equal(t, 1, current.Version, "failed preconditions change nothing")
+}
+
+// countedStorage reports reactions on top of the in-memory storage, whose
+// counters are always empty.
+type countedStorage struct {
+	product.Storage
+	reactions map[string]int
+}
+
+func (s *countedStorage) FindOne(ctx context.Context, id int) (*product.Product, error) {
+	p, err := s.Storage.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	p.Reactions = make(map[string]int, len(s.reactions))
+	for kind, count := range s.reactions {
+		p.Reactions[kind] = count
+	}
+	return p, nil
+}
+
+func TestProductHandlerETagAfterReaction(t *testing.T) {
+	logger := logging.GetLogger()
+	storage := &countedStorage{Storage: memory.NewProductRepository()}
+	s := newServer(t, api.NewPostHandler(logger, product.NewService(storage, noTx{}, logger)))
+	s.do(http.MethodPost, "/products/", `{"title": "lamp", "description": "bright", "owner_id": 1}`)
+
+	response := s.do(http.MethodGet, "/products/id/?id=1", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	tag := response.Header().Get("ETag")
+	assert.Equal(t, http.StatusNotModified, s.do(http.MethodGet, "/products/id/?id=1", "", "If-None-Match", tag).Code)
+
+	storage.reactions = map[string]int{"like": 1}
+	response = s.do(http.MethodGet, "/products/id/?id=1", "", "If-None-Match", tag)
+	require.Equal(t, http.StatusOK, response.Code, "a reaction changes the representation without a version bump")
+	var current product.Product
+	decode(t, response, &current)
+	assert.Equal(t, map[string]int{"like": 1}, current.Reactions)
+	assert.NotEqual(t, tag, response.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, s.do(http.MethodGet, "/products/id/?id=1", "", "If-None-Match", response.Header().Get("ETag")).Code)
+
+	update := `{"title": "desk lamp", "description": "brighter"}`
+	requireProblem(t, s.do(http.MethodPut, "/products/id/?id=1", update, "If-Match", tag, "Authorization", bearer(t, 1, user.RoleUser)), apperror.PreconditionFailed)
+}
+
+func TestProductHandlerErrors(t *testing.T) {
+	s := newProductServer(t)
+
+	requireProblem(t, s.do(http.MethodGet, "/products/id/?id=42", ""), apperror.ErrorNotFound)
+	requireProblem(t, s.do(http.MethodGet, "/products/id/?id=one", ""), apperror.IdQueryParamError)
+	requireProblem(t, s.do(http.MethodDelete, "/products/id/?id=42", ""), apperror.ErrorNotFound)
+	requireProblem(t, s.do(http.MethodGet, "/products/revisions/diff/?id=1&from=1&to=2", ""), apperror.ErrorNotFound)
+
+	s.do(http.MethodPost, "/products/", `{"title": "lamp", "description": "bright", "owner_id": 1}`)
+	response := s.do(http.MethodDelete, "/products/id/?id=1", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	requireProblem(t, s.do(http.MethodGet, "/products/id/?id=1", ""), apperror.ErrorNotFound)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"go.mod/internal"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/reaction"
+	"go.mod/pkg/jwt"
+	"go.mod/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+const (
+	productReactionsUrl = "/products/reactions/"
+	productBookmarksUrl = "/products/bookmarks/"
+	userBookmarksUrl    = "/users/bookmarks/"
+)
+
+type reactionHandler struct {
+	logger  *logging.Logger
+	service reaction.Service
+}
+
+func NewReactionHandler(logger *logging.Logger, s reaction.Service) internal.Handler {
+	return &reactionHandler{
+		logger:  logger,
+		service: s,
+	}
+}
+
+func (h reactionHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, productReactionsUrl, apperror.Middleware(h.GetCounters))
+	router.HandlerFunc(http.MethodPut, productReactionsUrl, jwt.Middleware(apperror.Middleware(h.React)))
+	router.HandlerFunc(http.MethodDelete, productReactionsUrl, jwt.Middleware(apperror.Middleware(h.React)))
+	router.HandlerFunc(http.MethodPut, productBookmarksUrl, jwt.Middleware(apperror.Middleware(h.Bookmark)))
+	router.HandlerFunc(http.MethodDelete, productBookmarksUrl, jwt.Middleware(apperror.Middleware(h.Bookmark)))
+	router.HandlerFunc(http.MethodGet, userBookmarksUrl, jwt.Middleware(apperror.Middleware(h.GetUserBookmarks)))
+}
+
+func intQueryParam(request *http.Request, name string) (int, error) {
+	value, err := strconv.Atoi(request.URL.Query().Get(name))
+	if err != nil {
+		return 0, apperror.BadRequestError("param " + name + " must be number")
+	}
+	return value, nil
+}
+
+func (h reactionHandler) GetCounters(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	productId, err := intQueryParam(request, "id")
+	if err != nil {
+		return err
+	}
+	counters, err := h.service.FindCounters(request.Context(), productId)
+	if err != nil {
+		return err
+	}
+	countersBytes, err := json.Marshal(counters)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(countersBytes)
+	return nil
+}
+
+func (h reactionHandler) React(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	productId, err := intQueryParam(request, "id")
+	if err != nil {
+		return err
+	}
+	userId, _, err := currentUser(request)
+	if err != nil {
+		return err
+	}
+	reactionObj := reaction.Reaction{
+		ProductId: productId,
+		UserId:    userId,
+		Kind:      request.URL.Query().Get("kind"),
+	}
+	if reactionObj.Kind == "" {
+		reactionObj.Kind = reaction.KindLike
+	}
+
+	var counters *reaction.Counters
+	switch request.Method {
+	case http.MethodPut:
+		counters, err = h.service.React(request.Context(), reactionObj)
+	case http.MethodDelete:
+		counters, err = h.service.Unreact(request.Context(), reactionObj)
+	}
+	if err != nil {
+		return err
+	}
+	countersBytes, err := json.Marshal(counters)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(countersBytes)
+	return nil
+}
+
+func (h reactionHandler) Bookmark(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	productId, err := intQueryParam(request, "id")
+	if err != nil {
+		return err
+	}
+	userId, _, err := currentUser(request)
+	if err != nil {
+		return err
+	}
+	bookmark := reaction.Bookmark{ProductId: productId, UserId: userId}
+
+	var counters *reaction.Counters
+	switch request.Method {
+	case http.MethodPut:
+		counters, err = h.service.Bookmark(request.Context(), bookmark)
+	case http.MethodDelete:
+		counters, err = h.service.Unbookmark(request.Context(), bookmark)
+	}
+	if err != nil {
+		return err
+	}
+	countersBytes, err := json.Marshal(counters)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(countersBytes)
+	return nil
+}
+
+func (h reactionHandler) GetUserBookmarks(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	userId, _, err := currentUser(request)
+	if err != nil {
+		return err
+	}
+	bookmarks, err := h.service.FindUserBookmarks(request.Context(), userId)
+	if err != nil {
+		return err
+	}
+	bookmarksBytes, err := json.Marshal(bookmarks)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(bookmarksBytes)
+	return nil
+}
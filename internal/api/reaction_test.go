@@ -0,0 +1,19 @@
+package api_test
+
+import (
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+// The service is nil, every request below is rejected before reaching it.
+func TestReactionRoutesRequireAuthentication(t *testing.T) {
+	s := newServer(t, api.NewReactionHandler(logging.GetLogger(), nil))
+
+	requireProblem(t, s.do(http.MethodPut, "/products/reactions/?id=1&user_id=2&kind=like", ""), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodDelete, "/products/reactions/?id=1&user_id=2", ""), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodPut, "/products/bookmarks/?id=1&user_id=2", ""), apperror.UnauthorizedError(""))
+	requireProblem(t, s.do(http.MethodGet, "/users/bookmarks/?user_id=2", ""), apperror.UnauthorizedError(""))
+}
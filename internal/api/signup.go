@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"go.mod/internal"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/signup"
+	"go.mod/pkg/logging"
+	"net/http"
+)
+
+const signupUrl = "/users/signup/"
+
+type signupHandler struct {
+	logger  *logging.Logger
+	service signup.Service
+}
+
+func NewSignupHandler(logger *logging.Logger, s signup.Service) internal.Handler {
+	return &signupHandler{
+		logger:  logger,
+		service: s,
+	}
+}
+
+func (h signupHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodPost, signupUrl, apperror.Middleware(h.Create))
+}
+
+func (h signupHandler) Create(w http.ResponseWriter, request *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	var createSignup signup.CreateSignupDTO
+	if err := json.NewDecoder(request.Body).Decode(&createSignup); err != nil {
+		return apperror.BadRequestError("can't decode")
+	}
+	created, err := h.service.Create(request.Context(), createSignup)
+	if err != nil {
+		return err
+	}
+	createdBytes, err := json.Marshal(created)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(createdBytes)
+	return nil
+}
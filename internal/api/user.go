@@ -1,7 +1,6 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"github.com/julienschmidt/httprouter"
 	"go.mod/internal"
@@ -70,10 +69,9 @@ func (h userHandler) Login(w http.ResponseWriter, request *http.Request) error {
 		username := request.URL.Query().Get("username")
 		password := request.URL.Query().Get("password")
 		if username == "" || password == "" {
-			w.WriteHeader(http.StatusBadRequest)
 			return apperror.BadRequestError("invalid query parameters email or password")
 		}
-		u, err := h.service.FindUserByUsernameAndPassword(context.TODO(), username, password)
+		u, err := h.service.FindUserByUsernameAndPassword(request.Context(), username, password)
 		if err != nil {
 			return err
 		}
@@ -84,7 +82,6 @@ func (h userHandler) Login(w http.ResponseWriter, request *http.Request) error {
 	case http.MethodPut:
 		var rt jwt.RT
 		if err := json.NewDecoder(request.Body).Decode(&rt); err != nil {
-			w.WriteHeader(http.StatusBadRequest)
 			return apperror.BadRequestError("failed to decode data")
 		}
 		token, err = h.JWTHelper.UpdateRefreshToken(rt)
@@ -99,10 +96,12 @@ func (h userHandler) Login(w http.ResponseWriter, request *http.Request) error {
 }
 
 func (h userHandler) GetList(w http.ResponseWriter, request *http.Request) error {
-	userList, err := h.service.FindAll(context.TODO())
+	userList, err := h.service.FindAll(request.Context())
+	if err != nil {
+		return err
+	}
 	userListBytes, err := json.Marshal(userList)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
@@ -114,23 +113,20 @@ func (h userHandler) CreateUser(w http.ResponseWriter, request *http.Request) er
 	w.Header().Set("Content-Type", "application/json")
 	var CreateUser user.CreateUserDTO
 	if err := json.NewDecoder(request.Body).Decode(&CreateUser); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return apperror.BadRequestError("can't decode")
 	}
 
-	userObj, err := h.service.Create(context.TODO(), CreateUser)
-	h.logger.Info("error after create\n", err)
+	userObj, err := h.service.Create(request.Context(), CreateUser)
+	logging.FromContext(request.Context()).Info("error after create\n", err)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	userObjBytes, err := json.Marshal(*userObj)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
-	w.Write(userObjBytes)
 	w.WriteHeader(http.StatusCreated)
+	w.Write(userObjBytes)
 	return nil
 }
 
@@ -141,23 +137,26 @@ func (h userHandler) UpdateUser(w http.ResponseWriter, request *http.Request) er
 	if err != nil {
 		return apperror.IdQueryParamError
 	}
-	userObj, err := h.service.FindOneById(context.TODO(), UserIdInt)
+	userObj, err := h.service.FindOneById(request.Context(), UserIdInt)
 	if err != nil {
 		return apperror.ErrorNotFound
 	}
+	if err := checkIfMatch(request, entityTag(userObj.Version)); err != nil {
+		return err
+	}
 	var updateUser user.UpdateUserDTO
 	if err := json.NewDecoder(request.Body).Decode(&updateUser); err != nil {
 		return apperror.BadRequestError("can't decode")
 	}
-	updatedUserObj, err := h.service.UserUpdate(context.TODO(), *userObj, updateUser)
+	updatedUserObj, err := h.service.UserUpdate(request.Context(), *userObj, updateUser)
 	if err != nil {
 		return err
 	}
 	updatedUserObjBytes, err := json.Marshal(updatedUserObj)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
+	w.Header().Set("ETag", entityTag(updatedUserObj.Version))
 	w.WriteHeader(http.StatusOK)
 	w.Write(updatedUserObjBytes)
 	return nil
@@ -169,13 +168,15 @@ func (h userHandler) GetUserById(w http.ResponseWriter, request *http.Request) e
 	if err != nil {
 		return apperror.IdQueryParamError
 	}
-	userObj, err := h.service.FindOneById(context.TODO(), userIdInt)
+	userObj, err := h.service.FindOneById(request.Context(), userIdInt)
 	if err != nil {
 		return apperror.ErrorNotFound
 	}
+	if notModified(w, request, entityTag(userObj.Version)) {
+		return nil
+	}
 	userObjBytes, err := json.Marshal(userObj)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
@@ -185,14 +186,15 @@ func (h userHandler) GetUserById(w http.ResponseWriter, request *http.Request) e
 
 func (h userHandler) GetUserByUsername(w http.ResponseWriter, request *http.Request) error {
 	username := request.URL.Query().Get("username")
-	userObj, err := h.service.FindOneByUsername(context.TODO(), username)
+	userObj, err := h.service.FindOneByUsername(request.Context(), username)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		return apperror.ErrorNotFound
+		return err
+	}
+	if notModified(w, request, entityTag(userObj.Version)) {
+		return nil
 	}
 	userObjBytes, err := json.Marshal(userObj)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
@@ -202,14 +204,15 @@ func (h userHandler) GetUserByUsername(w http.ResponseWriter, request *http.Requ
 
 func (h userHandler) GetUserByEmail(w http.ResponseWriter, request *http.Request) error {
 	email := request.URL.Query().Get("email")
-	userObj, err := h.service.FindOneByEmail(context.TODO(), email)
+	userObj, err := h.service.FindOneByEmail(request.Context(), email)
 	if err != nil {
 		return apperror.ErrorNotFound
 	}
-
+	if notModified(w, request, entityTag(userObj.Version)) {
+		return nil
+	}
 	userObjBytes, err := json.Marshal(userObj)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
@@ -224,9 +227,8 @@ func (h userHandler) DeleteUser(w http.ResponseWriter, request *http.Request) er
 	if err != nil {
 		return err
 	}
-	err = h.service.Delete(context.TODO(), userIdInt)
+	err = h.service.Delete(request.Context(), userIdInt)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,96 @@
+package api_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/api"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/internal/apps/user/memory"
+	"go.mod/pkg/jwt"
+	"go.mod/pkg/logging"
+	"net/http"
+	"testing"
+)
+
+// tokenStub issues a fixed token instead of signing one with the configured
+// secret.
+type tokenStub struct {
+	issuedFor []user.User
+}
+
+func (s *tokenStub) GenerateAccessToken(u user.User) ([]byte, error) {
+	s.issuedFor = append(s.issuedFor, u)
+	return []byte(`{"token":"access","refresh_token":"refresh"}`), nil
+}
+
+func (s *tokenStub) UpdateRefreshToken(rt jwt.RT) ([]byte, error) {
+	return nil, apperror.UnauthorizedError("unknown refresh token")
+}
+
+func newUserServer(t *testing.T) (*server, *tokenStub) {
+	logger := logging.GetLogger()
+	tokens := &tokenStub{}
+	service := user.NewUserService(memory.NewUserRepository(), logger)
+	return newServer(t, api.NewUserHandler(*logger, service, tokens)), tokens
+}
+
+const aliceJSON = `{"username": "alice", "email": "alice@example.com", "password": "s3cret", "repeat_password": "s3cret"}`
+
+func TestUserHandler(t *testing.T) {
+	s, _ := newUserServer(t)
+
+	response := s.do(http.MethodPost, "/users", aliceJSON)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	var created user.User
+	decode(t, response, &created)
+	assert.Equal(t, "alice", created.Username)
+	assert.NotContains(t, response.Body.String(), "s3cret")
+
+	requireProblem(t, s.do(http.MethodPost, "/users", aliceJSON), apperror.UserAlreadyExist)
+
+	response = s.do(http.MethodGet, "/users/id/?id=1", "")
+	require.Equal(t, http.StatusOK, response.Code)
+	var found user.User
+	decode(t, response, &found)
+	assert.Equal(t, created.ID, found.ID)
+
+	response = s.do(http.MethodGet, "/users/email/?email=alice@example.com", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var all []user.User
+	decode(t, s.do(http.MethodGet, "/users", ""), &all)
+	assert.Len(t, all, 1)
+
+	response = s.do(http.MethodPut, "/users/id/?id=1", `{"username": "alicia", "email": "alice@example.com"}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+
+	response = s.do(http.MethodDelete, "/users/id/?id=1", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	requireProblem(t, s.do(http.MethodGet, "/users/id/?id=1", ""), apperror.ErrorNotFound)
+}
+
+func TestUserHandlerLogin(t *testing.T) {
+	s, tokens := newUserServer(t)
+	s.do(http.MethodPost, "/users", aliceJSON)
+
+	response := s.do(http.MethodPost, "/users/login/?username=alice&password=s3cret", "")
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	require.Len(t, tokens.issuedFor, 1)
+	assert.Equal(t, "alice", tokens.issuedFor[0].Username)
+
+	requireProblem(t, s.do(http.MethodPost, "/users/login/?username=alice&password=wrong", ""), apperror.NotCorrectPassword)
+	requireProblem(t, s.do(http.MethodPost, "/users/login/?username=nobody&password=x", ""), apperror.ErrorNotFound)
+	response = s.do(http.MethodPost, "/users/login/?username=alice", "")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestUserHandlerErrors(t *testing.T) {
+	s, _ := newUserServer(t)
+
+	response := s.do(http.MethodPost, "/users", `{"username": "bob", "email": "bob@example.com", "password": "a", "repeat_password": "b"}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	requireProblem(t, s.do(http.MethodGet, "/users/id/?id=one", ""), apperror.IdQueryParamError)
+	requireProblem(t, s.do(http.MethodPut, "/users/id/?id=42", `{}`, "If-Match", `"1"`), apperror.ErrorNotFound)
+}
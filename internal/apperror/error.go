@@ -3,30 +3,92 @@ package apperror
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
 )
 
 var (
-	ErrorNotFound            = NewAppError("data not found", "US-000003", "")
-	CategoryTileAlreadyExist = NewAppError("category title already exist", "US-000005", "")
-	UserAlreadyExist         = NewAppError("username or email already exist", "US-000005", "")
-	ProductTitleAlreadyExist = NewAppError("product title already exist", "US-000005", "")
-	IdQueryParamError        = NewAppError("param id must be number", "US-00006", "")
-	NotCorrectPassword       = NewAppError("password is not correct", "US-0007", "")
+	ErrorNotFound            = define(http.StatusNotFound, "US-000003", "data not found")
+	ProductTitleAlreadyExist = define(http.StatusConflict, "US-000002", "product title already exist")
+	UserAlreadyExist         = define(http.StatusConflict, "US-000004", "username or email already exist")
+	CategoryTileAlreadyExist = define(http.StatusConflict, "US-000005", "category title already exist")
+	IdQueryParamError        = define(http.StatusBadRequest, "US-000006", "param id must be number")
+	NotCorrectPassword       = define(http.StatusUnauthorized, "US-000007", "password is not correct")
+	CommentEditWindowExpired = define(http.StatusForbidden, "US-000008", "comment edit window has expired")
+	CommentAlreadyDeleted    = define(http.StatusGone, "US-000009", "comment has been deleted")
+	CommentParentMismatch    = define(http.StatusUnprocessableEntity, "US-000010", "parent comment belongs to another product")
+	InvalidCommentStatus     = define(http.StatusBadRequest, "US-000011", "comment status must be pending, approved or rejected")
+	InvalidReactionKind      = define(http.StatusBadRequest, "US-000012", "reaction kind must be like, love, laugh, wow, sad or angry")
+	FileTooLarge             = define(http.StatusRequestEntityTooLarge, "US-000013", "file exceeds the maximum upload size")
+	UnsupportedMediaType     = define(http.StatusUnsupportedMediaType, "US-000014", "file type is not supported")
+	ImageTooLarge            = define(http.StatusUnprocessableEntity, "US-000015", "image dimensions exceed the allowed maximum")
+	InvalidSignedURL         = define(http.StatusForbidden, "US-000016", "download link is invalid or has expired")
+	PreconditionFailed       = define(http.StatusPreconditionFailed, "US-000017", "resource has been modified, reload it and retry")
+	PreconditionRequired     = define(http.StatusPreconditionRequired, "US-000018", "If-Match header is required")
+	CommentNotAuthor         = define(http.StatusForbidden, "US-000019", "only the author of the comment may change it")
+	ProductNotOwner          = define(http.StatusForbidden, "US-000020", "only the owner of the product may change its attachments")
+
+	errSystem       = define(http.StatusInternalServerError, "NS-000001", "system error")
+	errBadRequest   = define(http.StatusBadRequest, "NS-000002", "bad request")
+	errUnauthorized = define(http.StatusUnauthorized, "NS-000003", "unauthorized")
+	errForbidden    = define(http.StatusForbidden, "NS-000006", "forbidden")
+
+	// RequestCanceled reports work abandoned because the client went away.
+	// Nobody reads the response, the status follows the nginx convention.
+	RequestCanceled = define(StatusClientClosedRequest, "NS-000004", "request was cancelled by the client")
+	RequestTimeout  = define(http.StatusServiceUnavailable, "NS-000005", "request did not complete in time")
 )
 
+// StatusClientClosedRequest is the non standard status used for requests the
+// client abandoned before a response was written.
+const StatusClientClosedRequest = 499
+
+// registry holds every error defined with define, keyed by its code.
+var registry = make(map[string]*AppError)
+
 type AppError struct {
 	Err              error  `json:"-"`
 	Message          string `json:"message"`
 	DeveloperMessage string `json:"developer_message"`
 	Code             string `json:"code"`
+	Status           int    `json:"-"`
 }
 
 func (e *AppError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
 	return e.Err.Error()
 }
 
 func (e *AppError) Unwrap() error { return e.Err }
 
+// Is matches errors by code, so copies produced by Wrap match the error they were made from.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// WithStatus sets the HTTP status the error is reported with and returns e.
+func (e *AppError) WithStatus(status int) *AppError {
+	e.Status = status
+	return e
+}
+
+// Wrap returns a copy of e caused by err, with a developer message explaining it.
+func (e *AppError) Wrap(err error, developerMessage string) *AppError {
+	wrapped := *e
+	wrapped.Err = err
+	wrapped.DeveloperMessage = developerMessage
+	return &wrapped
+}
+
+func (e *AppError) withMessage(message, developerMessage string) *AppError {
+	wrapped := e.Wrap(fmt.Errorf(message), developerMessage)
+	wrapped.Message = message
+	return wrapped
+}
+
 func (e *AppError) Marshal() []byte {
 	marshal, err := json.Marshal(e)
 	if err != nil {
@@ -42,21 +104,54 @@ func NewAppError(message, code, developerMessage string) *AppError {
 		Message:          message,
 		DeveloperMessage: developerMessage,
 		Code:             code,
+		Status:           http.StatusBadRequest,
+	}
+}
+
+// define registers a package level error. Codes identify the problem type in
+// responses, so defining one twice is a programming error.
+func define(status int, code, message string) *AppError {
+	if _, ok := registry[code]; ok {
+		panic("apperror: duplicate error code " + code)
 	}
+	e := NewAppError(message, code, "").WithStatus(status)
+	registry[code] = e
+	return e
+}
+
+// Registered returns every defined error ordered by code.
+func Registered() []*AppError {
+	errs := make([]*AppError, 0, len(registry))
+	for _, e := range registry {
+		errs = append(errs, e)
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Code < errs[j].Code })
+	return errs
 }
 
 func systemError(developerMessage string) *AppError {
-	return NewAppError("system error", "NS-000001", developerMessage)
+	return errSystem.Wrap(fmt.Errorf("system error: %s", developerMessage), developerMessage)
 }
 
 func BadRequestError(message string) *AppError {
-	return NewAppError(message, "NS-000002", "some thing wrong with data")
+	return errBadRequest.withMessage(message, "some thing wrong with data")
 }
 
+// APIError returns the error defined with code carrying message and
+// developerMessage, with the status it was defined with. An unknown code is
+// reported as a system error, so responses only ever carry defined codes.
 func APIError(code, message, developerMessage string) *AppError {
-	return NewAppError(message, code, developerMessage)
+	e, ok := registry[code]
+	if !ok {
+		return systemError(fmt.Sprintf("undefined error code %s: %s", code, developerMessage))
+	}
+	return e.withMessage(message, developerMessage)
 }
 
 func UnauthorizedError(message string) *AppError {
-	return NewAppError(message, "NS-000003", "")
+	return errUnauthorized.withMessage(message, "")
+}
+
+func ForbiddenError(message string) *AppError {
+	return errForbidden.withMessage(message, "")
 }
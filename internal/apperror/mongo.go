@@ -0,0 +1,37 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FromMongo translates an error returned by the MongoDB driver the way
+// FromPostgres does for pgx, so both storage backends report the same typed
+// errors. A duplicate key is reported as uniqueViolation when given.
+func FromMongo(err error, uniqueViolation ...*AppError) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrorNotFound.Wrap(err, "no documents in result")
+	}
+	if errors.Is(err, context.Canceled) {
+		return RequestCanceled.Wrap(err, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err) {
+		return QueryTimeout.Wrap(err, err.Error())
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		target := UniqueViolation
+		if len(uniqueViolation) > 0 {
+			target = uniqueViolation[0]
+		}
+		return target.Wrap(err, err.Error())
+	}
+	return errSystem.Wrap(err, "MongoDB Error: "+err.Error())
+}
@@ -0,0 +1,69 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"net/http"
+)
+
+var (
+	UniqueViolation = define(http.StatusConflict, "DB-000001", "resource already exists")
+	// ForeignKeyViolation is a conflict with the current state rather than
+	// a missing resource: Postgres reports a reference to a row that does not
+	// exist and a delete of a row still referenced with the same code.
+	ForeignKeyViolation  = define(http.StatusConflict, "DB-000002", "referenced resource does not exist or is still in use")
+	CheckViolation       = define(http.StatusUnprocessableEntity, "DB-000003", "value is out of the allowed range")
+	NotNullViolation     = define(http.StatusUnprocessableEntity, "DB-000004", "required value is missing")
+	SerializationFailure = define(http.StatusConflict, "DB-000005", "resource is being modified concurrently, retry the request")
+	QueryTimeout         = define(http.StatusGatewayTimeout, "DB-000006", "database did not answer in time")
+)
+
+// FromPostgres translates an error returned by pgx into a typed AppError that
+// names the offending constraint or column in its developer message. The pgx
+// error stays reachable through errors.As. A unique violation is reported as
+// uniqueViolation when given, so repositories keep their specific messages.
+func FromPostgres(err error, uniqueViolation ...*AppError) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrorNotFound.Wrap(err, "no rows in result set")
+	}
+	if errors.Is(err, context.Canceled) {
+		return RequestCanceled.Wrap(err, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return QueryTimeout.Wrap(err, err.Error())
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case "23505":
+		target := UniqueViolation
+		if len(uniqueViolation) > 0 {
+			target = uniqueViolation[0]
+		}
+		return target.Wrap(err, fmt.Sprintf("unique constraint %q violated: %s", pgErr.ConstraintName, pgErr.Detail))
+	case "23503":
+		return ForeignKeyViolation.Wrap(err, fmt.Sprintf("foreign key constraint %q violated: %s", pgErr.ConstraintName, pgErr.Detail))
+	case "23514":
+		return CheckViolation.Wrap(err, fmt.Sprintf("check constraint %q violated on %s", pgErr.ConstraintName, pgErr.TableName))
+	case "23502":
+		return NotNullViolation.Wrap(err, fmt.Sprintf("column %q of %s must not be null", pgErr.ColumnName, pgErr.TableName))
+	case "40001", "40P01":
+		return SerializationFailure.Wrap(err, pgErr.Message)
+	case "57014", "55P03":
+		return QueryTimeout.Wrap(err, pgErr.Message)
+	}
+	return errSystem.Wrap(err, fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
+}
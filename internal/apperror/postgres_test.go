@@ -0,0 +1,78 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestFromPostgres(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		want      *AppError
+		status    int
+		developer string
+	}{
+		{"no rows", pgx.ErrNoRows, ErrorNotFound, http.StatusNotFound, "no rows in result set"},
+		{"wrapped no rows", fmt.Errorf("find product: %w", pgx.ErrNoRows), ErrorNotFound, http.StatusNotFound, "no rows in result set"},
+		{"cancelled", context.Canceled, RequestCanceled, StatusClientClosedRequest, "context canceled"},
+		{"deadline", context.DeadlineExceeded, QueryTimeout, http.StatusGatewayTimeout, "context deadline exceeded"},
+		{
+			"unique", &pgconn.PgError{Code: "23505", ConstraintName: "product_title_key", Detail: "Key (title)=(lamp) already exists."},
+			UniqueViolation, http.StatusConflict, `unique constraint "product_title_key" violated: Key (title)=(lamp) already exists.`,
+		},
+		{
+			"missing reference", &pgconn.PgError{Code: "23503", ConstraintName: "comment_product_id_fkey", Detail: `Key (product_id)=(42) is not present in table "product".`},
+			ForeignKeyViolation, http.StatusConflict, `foreign key constraint "comment_product_id_fkey" violated: Key (product_id)=(42) is not present in table "product".`,
+		},
+		{
+			"still referenced", &pgconn.PgError{Code: "23503", ConstraintName: "comment_product_id_fkey", Detail: `Key (id)=(1) is still referenced from table "comment".`},
+			ForeignKeyViolation, http.StatusConflict, `foreign key constraint "comment_product_id_fkey" violated: Key (id)=(1) is still referenced from table "comment".`,
+		},
+		{
+			"check", &pgconn.PgError{Code: "23514", ConstraintName: "user_role_check", TableName: "user"},
+			CheckViolation, http.StatusUnprocessableEntity, `check constraint "user_role_check" violated on user`,
+		},
+		{
+			"not null", &pgconn.PgError{Code: "23502", ColumnName: "title", TableName: "product"},
+			NotNullViolation, http.StatusUnprocessableEntity, `column "title" of product must not be null`,
+		},
+		{"serialization", &pgconn.PgError{Code: "40001", Message: "could not serialize access"}, SerializationFailure, http.StatusConflict, "could not serialize access"},
+		{"deadlock", &pgconn.PgError{Code: "40P01", Message: "deadlock detected"}, SerializationFailure, http.StatusConflict, "deadlock detected"},
+		{"statement timeout", &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"}, QueryTimeout, http.StatusGatewayTimeout, "canceling statement due to statement timeout"},
+		{"lock timeout", &pgconn.PgError{Code: "55P03", Message: "could not obtain lock"}, QueryTimeout, http.StatusGatewayTimeout, "could not obtain lock"},
+		{"other", &pgconn.PgError{Code: "42P01", Message: `relation "products" does not exist`}, errSystem, http.StatusInternalServerError, `SQL Error: relation "products" does not exist`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FromPostgres(tt.err)
+			var appErr *AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, tt.status, appErr.Status)
+			assert.Contains(t, appErr.DeveloperMessage, tt.developer)
+			assert.ErrorIs(t, err, tt.err, "the cause stays reachable")
+		})
+	}
+}
+
+func TestFromPostgresPassesThrough(t *testing.T) {
+	assert.NoError(t, FromPostgres(nil))
+
+	notPostgres := errors.New("connection reset")
+	assert.Equal(t, notPostgres, FromPostgres(notPostgres))
+
+	already := ProductTitleAlreadyExist.Wrap(errors.New("duplicate"), "duplicate")
+	assert.Same(t, already, FromPostgres(already))
+
+	unique := &pgconn.PgError{Code: "23505", ConstraintName: "category_title_key"}
+	assert.ErrorIs(t, FromPostgres(unique, CategoryTileAlreadyExist), CategoryTileAlreadyExist, "repositories keep their own message")
+	assert.NotErrorIs(t, FromPostgres(unique, CategoryTileAlreadyExist), UniqueViolation)
+}
@@ -0,0 +1,116 @@
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"go.mod/pkg/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes the error code to build the problem type URI.
+var ProblemTypeBase = "/problems/"
+
+var debug atomic.Bool
+
+// SetDebug controls whether developer messages are included in responses.
+// They describe queries and internals, so it must stay off in production.
+func SetDebug(on bool) {
+	debug.Store(on)
+}
+
+// Problem is an RFC 7807 problem details object. Code, TraceID and
+// DeveloperMessage are extension members.
+type Problem struct {
+	Type             string `json:"type"`
+	Title            string `json:"title"`
+	Status           int    `json:"status"`
+	Detail           string `json:"detail,omitempty"`
+	Instance         string `json:"instance,omitempty"`
+	Code             string `json:"code"`
+	TraceID          string `json:"trace_id,omitempty"`
+	DeveloperMessage string `json:"developer_message,omitempty"`
+}
+
+// Problem describes e as it occurred on instance, the request URI.
+func (e *AppError) Problem(instance, traceID string) Problem {
+	status := e.Status
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+	p := Problem{
+		Type:     ProblemTypeBase + strings.ToLower(e.Code),
+		Title:    statusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		TraceID:  traceID,
+	}
+	if debug.Load() {
+		p.DeveloperMessage = e.DeveloperMessage
+	}
+	return p
+}
+
+// WriteProblem writes err as a problem+json response. Errors that are not an
+// AppError are reported as a system error so their text never reaches clients
+// outside debug mode.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *AppError
+	switch {
+	case errors.As(err, &appErr):
+	case errors.Is(err, context.Canceled):
+		appErr = RequestCanceled.Wrap(err, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		appErr = RequestTimeout.Wrap(err, err.Error())
+	default:
+		appErr = systemError(err.Error())
+	}
+
+	p := appErr.Problem(r.URL.RequestURI(), traceID(w, r))
+	if appErr.Code == RequestCanceled.Code {
+		logging.FromContext(r.Context()).Infof("client cancelled %s %s", r.Method, p.Instance)
+	} else if p.Status >= http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Errorf("%s %s: %s (trace_id=%s)", r.Method, p.Instance, appErr.DeveloperMessage, p.TraceID)
+	}
+	body, mErr := json.Marshal(p)
+	if mErr != nil {
+		body = []byte(`{"type":"about:blank","status":500}`)
+		p.Status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
+
+func statusText(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+// traceID returns the id of the trace the request is part of, so the problem
+// can be looked up in the tracing backend. Without a recording span it falls
+// back to the request id.
+func traceID(w http.ResponseWriter, r *http.Request) string {
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	if id := w.Header().Get("X-Request-ID"); id != "" {
+		return id
+	}
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		return id
+	}
+	return uuid.NewString()
+}
@@ -0,0 +1,127 @@
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceID(t *testing.T) {
+	request := httptest.NewRequest("GET", "/products/", nil)
+	request.Header.Set("X-Request-ID", "from-client")
+	recorder := httptest.NewRecorder()
+	assert.Equal(t, "from-client", traceID(recorder, request))
+
+	recorder.Header().Set("X-Request-ID", "assigned")
+	assert.Equal(t, "assigned", traceID(recorder, request))
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	request = request.WithContext(trace.ContextWithSpanContext(context.Background(), sc))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID(recorder, request), "the span context wins")
+
+	assert.NotEmpty(t, traceID(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)))
+}
+
+func TestWriteProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Problem
+	}{
+		{
+			name: "defined error",
+			err:  fmt.Errorf("update product: %w", PreconditionFailed.Wrap(errors.New("version 2"), "stale If-Match")),
+			want: Problem{Type: "/problems/us-000017", Title: "Precondition Failed", Status: http.StatusPreconditionFailed,
+				Detail: "resource has been modified, reload it and retry", Code: "US-000017"},
+		},
+		{
+			name: "api error",
+			err:  APIError("US-000003", "product 42 not found", "no rows"),
+			want: Problem{Type: "/problems/us-000003", Title: "Not Found", Status: http.StatusNotFound,
+				Detail: "product 42 not found", Code: "US-000003"},
+		},
+		{
+			name: "undefined api error",
+			err:  APIError("XX-000001", "made up", "no such code"),
+			want: Problem{Type: "/problems/ns-000001", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+				Detail: "system error", Code: "NS-000001"},
+		},
+		{
+			name: "plain error",
+			err:  errors.New(`dial tcp 10.0.3.7:5432: connection refused`),
+			want: Problem{Type: "/problems/ns-000001", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+				Detail: "system error", Code: "NS-000001"},
+		},
+		{
+			name: "cancelled",
+			err:  fmt.Errorf("find products: %w", context.Canceled),
+			want: Problem{Type: "/problems/ns-000004", Title: "Client Closed Request", Status: StatusClientClosedRequest,
+				Detail: "request was cancelled by the client", Code: "NS-000004"},
+		},
+		{
+			name: "timed out",
+			err:  context.DeadlineExceeded,
+			want: Problem{Type: "/problems/ns-000005", Title: "Service Unavailable", Status: http.StatusServiceUnavailable,
+				Detail: "request did not complete in time", Code: "NS-000005"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPut, "/products/id/?id=42", nil)
+			request.Header.Set("X-Request-ID", "req-1")
+			recorder := httptest.NewRecorder()
+			WriteProblem(recorder, request, tt.err)
+
+			assert.Equal(t, tt.want.Status, recorder.Code)
+			assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+			var got Problem
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+			tt.want.Instance = "/products/id/?id=42"
+			tt.want.TraceID = "req-1"
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteProblemDeveloperMessage(t *testing.T) {
+	err := errors.New(`relation "products" does not exist`)
+	write := func() Problem {
+		recorder := httptest.NewRecorder()
+		WriteProblem(recorder, httptest.NewRequest(http.MethodGet, "/products/", nil), err)
+		var p Problem
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &p))
+		return p
+	}
+
+	assert.Empty(t, write().DeveloperMessage, "internals stay out of responses")
+	SetDebug(true)
+	defer SetDebug(false)
+	assert.Equal(t, err.Error(), write().DeveloperMessage)
+}
+
+func TestAPIError(t *testing.T) {
+	err := APIError(ProductTitleAlreadyExist.Code, "lamp already exists", "unique title")
+	assert.ErrorIs(t, err, ProductTitleAlreadyExist)
+	assert.Equal(t, http.StatusConflict, err.Status, "the status comes from the definition")
+	assert.Equal(t, "lamp already exists", err.Message)
+	assert.Equal(t, "unique title", err.DeveloperMessage)
+	assert.Equal(t, "product title already exist", ProductTitleAlreadyExist.Message, "the definition is left alone")
+
+	undefined := APIError("XX-000001", "made up", "no such code")
+	assert.ErrorIs(t, undefined, errSystem)
+	assert.Contains(t, undefined.DeveloperMessage, "XX-000001")
+	for _, e := range Registered() {
+		assert.NotEqual(t, "XX-000001", e.Code, "APIError does not define codes")
+	}
+}
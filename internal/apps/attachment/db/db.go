@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/attachment"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/logging"
+	"go.mod/pkg/utils"
+)
+
+const attachmentColumns = `id, product_id, key, thumbnail_key, filename, content_type, size, width, height, created_at`
+
+type attachmentRepository struct {
+	client postgresql.Client
+	logger *logging.Logger
+}
+
+func NewAttachmentRepository(client postgresql.Client, logger *logging.Logger) attachment.Storage {
+	return &attachmentRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func scanAttachment(row pgx.Row, a *attachment.Attachment) error {
+	return row.Scan(&a.ID, &a.ProductId, &a.Key, &a.ThumbnailKey, &a.Filename, &a.ContentType, &a.Size, &a.Width, &a.Height, &a.CreatedAt)
+}
+
+func (r *attachmentRepository) Create(ctx context.Context, attachmentDTO attachment.CreateAttachmentDTO) (a *attachment.Attachment, err error) {
+	ctx = postgresql.Named(ctx, "attachment", "Create")
+	q := `
+	INSERT INTO public.product_attachment (product_id, key, thumbnail_key, filename, content_type, size, width, height)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING ` + attachmentColumns
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	var attachmentObj attachment.Attachment
+	row := r.client.QueryRow(ctx, q, attachmentDTO.ProductId, attachmentDTO.Key, attachmentDTO.ThumbnailKey, attachmentDTO.Filename,
+		attachmentDTO.ContentType, attachmentDTO.Size, attachmentDTO.Width, attachmentDTO.Height)
+	if err := scanAttachment(row, &attachmentObj); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return &attachmentObj, nil
+}
+
+func (r *attachmentRepository) FindOne(ctx context.Context, id int) (a *attachment.Attachment, err error) {
+	ctx = postgresql.Named(ctx, "attachment", "FindOne")
+	q := `SELECT ` + attachmentColumns + ` FROM public.product_attachment WHERE id = $1`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	var attachmentObj attachment.Attachment
+	if err := scanAttachment(r.client.QueryRow(ctx, q, id), &attachmentObj); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return &attachmentObj, nil
+}
+
+func (r *attachmentRepository) FindProductAttachments(ctx context.Context, productId int) ([]attachment.Attachment, error) {
+	ctx = postgresql.Named(ctx, "attachment", "FindProductAttachments")
+	q := `
+	SELECT ` + attachmentColumns + `
+	FROM public.product_attachment
+	WHERE product_id = $1
+	ORDER BY created_at, id`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	query, err := r.client.Query(ctx, q, productId)
+	if err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	defer query.Close()
+
+	attachments := make([]attachment.Attachment, 0)
+	for query.Next() {
+		var attachmentInfo attachment.Attachment
+		if err := scanAttachment(query, &attachmentInfo); err != nil {
+			return nil, apperror.FromPostgres(err)
+		}
+		attachments = append(attachments, attachmentInfo)
+	}
+	if err = query.Err(); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return attachments, nil
+}
+
+func (r *attachmentRepository) Delete(ctx context.Context, id int) error {
+	ctx = postgresql.Named(ctx, "attachment", "Delete")
+	q := `
+	DELETE FROM public.product_attachment WHERE id = $1
+	`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	tag, err := r.client.Exec(ctx, q, id)
+	if err != nil {
+		return apperror.FromPostgres(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrorNotFound
+	}
+	return nil
+}
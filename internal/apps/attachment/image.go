@@ -0,0 +1,44 @@
+package attachment
+
+import (
+	"bytes"
+	xdraw "golang.org/x/image/draw"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+// thumbnail scales img down to fit a size x size box, keeping its aspect
+// ratio, and encodes it as JPEG on a white background.
+func thumbnail(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			height = height * size / width
+			width = size
+		} else {
+			width = width * size / height
+			height = size
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	rect := image.Rect(0, 0, width, height)
+	dst := image.NewRGBA(rect)
+	draw.Draw(dst, rect, image.White, image.Point{}, draw.Src)
+	xdraw.CatmullRom.Scale(dst, rect, img, bounds, xdraw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,59 @@
+package attachment
+
+import "time"
+
+const (
+	VariantOriginal  = "original"
+	VariantThumbnail = "thumbnail"
+)
+
+type CreateAttachmentDTO struct {
+	ProductId    int
+	Key          string
+	ThumbnailKey *string
+	Filename     string
+	ContentType  string
+	Size         int64
+	Width        int
+	Height       int
+}
+
+type Attachment struct {
+	ID           int       `json:"id"`
+	ProductId    int       `json:"product_id"`
+	Key          string    `json:"-"`
+	ThumbnailKey *string   `json:"-"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url,omitempty"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+}
+
+// BlobKey returns the blob store key of the requested variant, or false when
+// the attachment has no such variant.
+func (a *Attachment) BlobKey(variant string) (string, bool) {
+	switch variant {
+	case VariantOriginal, "":
+		return a.Key, true
+	case VariantThumbnail:
+		if a.ThumbnailKey != nil {
+			return *a.ThumbnailKey, true
+		}
+	}
+	return "", false
+}
+
+// Options holds the upload limits and URL signing settings of the service.
+type Options struct {
+	MaxSize       int64
+	MaxWidth      int
+	MaxHeight     int
+	ThumbnailSize int
+	URLTTL        time.Duration
+	SigningKey    []byte
+	DownloadURL   string
+}
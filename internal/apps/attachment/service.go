@@ -0,0 +1,220 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"go.mod/pkg/blobstore"
+	"go.mod/pkg/logging"
+	"image"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const blobCleanupTimeout = 30 * time.Second
+
+// allowedTypes maps the sniffed MIME types accepted for upload to the file
+// extension used for their blob key.
+var allowedTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+// Service changes the attachments of a product only for userId, the owner of
+// the product.
+type Service interface {
+	Upload(ctx context.Context, productId, userId int, filename string, r io.Reader) (a *Attachment, err error)
+	FindProductAttachments(ctx context.Context, productId int) ([]Attachment, error)
+	Open(ctx context.Context, id int, variant string, expires int64, sig string) (a *Attachment, content io.ReadCloser, err error)
+	Delete(ctx context.Context, id, userId int) error
+}
+
+type attachmentService struct {
+	storage  Storage
+	products product.Service
+	blobs    blobstore.BlobStore
+	options  Options
+	logger   *logging.Logger
+}
+
+func NewService(storage Storage, products product.Service, blobs blobstore.BlobStore, options Options, logger *logging.Logger) Service {
+	if options.ThumbnailSize <= 0 {
+		options.ThumbnailSize = 256
+	}
+	if options.DownloadURL == "" {
+		options.DownloadURL = "/media/"
+	}
+	return &attachmentService{
+		storage:  storage,
+		products: products,
+		blobs:    blobs,
+		options:  options,
+		logger:   logger,
+	}
+}
+
+// checkOwner rejects users other than the owner of the product.
+func (s *attachmentService) checkOwner(ctx context.Context, productId, userId int) error {
+	p, err := s.products.FindOneById(ctx, productId)
+	if err != nil {
+		return err
+	}
+	if p.OwnerId != userId {
+		return apperror.ProductNotOwner
+	}
+	return nil
+}
+
+func (s *attachmentService) Upload(ctx context.Context, productId, userId int, filename string, r io.Reader) (a *Attachment, err error) {
+	if err := s.checkOwner(ctx, productId, userId); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, s.options.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.options.MaxSize {
+		return nil, apperror.FileTooLarge
+	}
+	if len(data) == 0 {
+		return nil, apperror.BadRequestError("file is empty")
+	}
+
+	// never trust the client supplied Content-Type, sniff the bytes instead
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, apperror.UnsupportedMediaType
+	}
+
+	dto := CreateAttachmentDTO{
+		ProductId:   productId,
+		Key:         fmt.Sprintf("products/%d/%s%s", productId, uuid.New(), ext),
+		Filename:    path.Base(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+
+	var thumb []byte
+	if strings.HasPrefix(contentType, "image/") {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, apperror.UnsupportedMediaType
+		}
+		if config.Width > s.options.MaxWidth || config.Height > s.options.MaxHeight {
+			return nil, apperror.ImageTooLarge
+		}
+		dto.Width, dto.Height = config.Width, config.Height
+
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, apperror.UnsupportedMediaType
+		}
+		thumb, err = thumbnail(img, s.options.ThumbnailSize)
+		if err != nil {
+			return nil, err
+		}
+		thumbKey := fmt.Sprintf("products/%d/thumb_%s.jpg", productId, uuid.New())
+		dto.ThumbnailKey = &thumbKey
+	}
+
+	if err := s.blobs.Put(ctx, dto.Key, bytes.NewReader(data), dto.Size, contentType); err != nil {
+		return nil, err
+	}
+	if thumb != nil {
+		if err := s.blobs.Put(ctx, *dto.ThumbnailKey, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+			s.deleteBlobs(dto.Key, nil)
+			return nil, err
+		}
+	}
+
+	created, err := s.storage.Create(ctx, dto)
+	if err != nil {
+		s.deleteBlobs(dto.Key, dto.ThumbnailKey)
+		return nil, err
+	}
+	s.sign(created, time.Now())
+	return created, nil
+}
+
+func (s *attachmentService) FindProductAttachments(ctx context.Context, productId int) ([]Attachment, error) {
+	attachments, err := s.storage.FindProductAttachments(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range attachments {
+		s.sign(&attachments[i], now)
+	}
+	return attachments, nil
+}
+
+func (s *attachmentService) Open(ctx context.Context, id int, variant string, expires int64, sig string) (a *Attachment, content io.ReadCloser, err error) {
+	if !s.verify(id, variant, expires, sig, time.Now()) {
+		return nil, nil, apperror.InvalidSignedURL
+	}
+	a, err = s.storage.FindOne(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := a.BlobKey(variant)
+	if !ok {
+		return nil, nil, apperror.ErrorNotFound
+	}
+	content, err = s.blobs.Get(ctx, key)
+	if err != nil {
+		if err == blobstore.ErrNotFound {
+			return nil, nil, apperror.ErrorNotFound
+		}
+		return nil, nil, err
+	}
+	return a, content, nil
+}
+
+func (s *attachmentService) Delete(ctx context.Context, id, userId int) error {
+	a, err := s.storage.FindOne(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwner(ctx, a.ProductId, userId); err != nil {
+		return err
+	}
+	if err := s.storage.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.deleteBlobs(a.Key, a.ThumbnailKey)
+	return nil
+}
+
+func (s *attachmentService) sign(a *Attachment, now time.Time) {
+	a.URL = s.signURL(a.ID, VariantOriginal, now)
+	if a.ThumbnailKey != nil {
+		a.ThumbnailURL = s.signURL(a.ID, VariantThumbnail, now)
+	}
+}
+
+// deleteBlobs removes stored objects on a best effort basis, an orphaned blob
+// is only wasted space so failures are logged and not returned. It runs
+// detached from the request so a client disconnect does not leave blobs behind.
+func (s *attachmentService) deleteBlobs(key string, thumbnailKey *string) {
+	ctx, cancel := context.WithTimeout(context.Background(), blobCleanupTimeout)
+	defer cancel()
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		logging.FromContext(ctx).Warnf("failed to delete blob %s: %v", key, err)
+	}
+	if thumbnailKey != nil {
+		if err := s.blobs.Delete(ctx, *thumbnailKey); err != nil {
+			logging.FromContext(ctx).Warnf("failed to delete blob %s: %v", *thumbnailKey, err)
+		}
+	}
+}
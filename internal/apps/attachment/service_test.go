@@ -0,0 +1,256 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"go.mod/pkg/blobstore"
+	"go.mod/pkg/blobstore/memory"
+	"go.mod/pkg/logging"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// storage keeps attachments by id and fails Create when err is set.
+type storage struct {
+	attachments map[int]*Attachment
+	nextID      int
+	err         error
+}
+
+func (s *storage) Create(_ context.Context, dto CreateAttachmentDTO) (*Attachment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.nextID++
+	a := &Attachment{ID: s.nextID, ProductId: dto.ProductId, Key: dto.Key, ThumbnailKey: dto.ThumbnailKey, Filename: dto.Filename,
+		ContentType: dto.ContentType, Size: dto.Size, Width: dto.Width, Height: dto.Height, CreatedAt: time.Now()}
+	s.attachments[a.ID] = a
+	one := *a
+	return &one, nil
+}
+
+func (s *storage) FindOne(_ context.Context, id int) (*Attachment, error) {
+	a, ok := s.attachments[id]
+	if !ok {
+		return nil, apperror.ErrorNotFound
+	}
+	one := *a
+	return &one, nil
+}
+
+func (s *storage) FindProductAttachments(_ context.Context, productId int) ([]Attachment, error) {
+	attachments := make([]Attachment, 0)
+	for _, a := range s.attachments {
+		if a.ProductId == productId {
+			attachments = append(attachments, *a)
+		}
+	}
+	return attachments, nil
+}
+
+func (s *storage) Delete(_ context.Context, id int) error {
+	if _, ok := s.attachments[id]; !ok {
+		return apperror.ErrorNotFound
+	}
+	delete(s.attachments, id)
+	return nil
+}
+
+// products are all owned by user 1, only FindOneById is used.
+type products struct {
+	product.Service
+}
+
+func (products) FindOneById(_ context.Context, id int) (*product.Product, error) {
+	return &product.Product{ID: id, OwnerId: 1}, nil
+}
+
+// blobs wraps the memory store to count the stored objects.
+type blobs struct {
+	blobstore.BlobStore
+	keys map[string]bool
+}
+
+func (b *blobs) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	b.keys[key] = true
+	return b.BlobStore.Put(ctx, key, r, size, contentType)
+}
+
+func (b *blobs) Delete(ctx context.Context, key string) error {
+	delete(b.keys, key)
+	return b.BlobStore.Delete(ctx, key)
+}
+
+func newService() (*attachmentService, *storage, *blobs) {
+	st := &storage{attachments: make(map[int]*Attachment)}
+	b := &blobs{BlobStore: memory.NewBlobStore(), keys: make(map[string]bool)}
+	s := NewService(st, products{}, b, Options{
+		MaxSize:       1 << 20,
+		MaxWidth:      400,
+		MaxHeight:     300,
+		ThumbnailSize: 64,
+		URLTTL:        time.Minute,
+		SigningKey:    []byte("test-signing-key"),
+	}, logging.GetLogger())
+	return s.(*attachmentService), st, b
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// signedQuery returns the id, variant, expires and sig parameters of url.
+func signedQuery(t *testing.T, signed string) (int, string, int64, string) {
+	t.Helper()
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	query := u.Query()
+	id, err := strconv.Atoi(query.Get("id"))
+	require.NoError(t, err)
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	require.NoError(t, err)
+	return id, query.Get("variant"), expires, query.Get("sig")
+}
+
+// tamper changes the first character of sig.
+func tamper(sig string) string {
+	if sig[0] == 'A' {
+		return "B" + sig[1:]
+	}
+	return "A" + sig[1:]
+}
+
+func TestUploadRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "html", data: []byte("<html><body>hi</body></html>"), want: apperror.UnsupportedMediaType},
+		{name: "executable", data: []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00"), want: apperror.UnsupportedMediaType},
+		{name: "corrupt png", data: []byte("\x89PNG\r\n\x1a\nnot really"), want: apperror.UnsupportedMediaType},
+		{name: "too wide", data: encodePNG(t, 401, 10), want: apperror.ImageTooLarge},
+		{name: "too high", data: encodePNG(t, 10, 301), want: apperror.ImageTooLarge},
+		{name: "too large", data: bytes.Repeat([]byte("a"), 1<<20+1), want: apperror.FileTooLarge},
+		{name: "empty", data: nil, want: apperror.BadRequestError("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, st, b := newService()
+			_, err := s.Upload(context.Background(), 1, 1, "file", bytes.NewReader(tt.data))
+			require.ErrorIs(t, err, tt.want)
+			assert.Empty(t, st.attachments)
+			assert.Empty(t, b.keys)
+		})
+	}
+}
+
+func TestUploadStoresThumbnail(t *testing.T) {
+	s, _, b := newService()
+	a, err := s.Upload(context.Background(), 1, 1, "../../photo.png", bytes.NewReader(encodePNG(t, 400, 200)))
+	require.NoError(t, err)
+	assert.Equal(t, "photo.png", a.Filename, "directories of the client file name are dropped")
+	assert.Equal(t, "image/png", a.ContentType)
+	assert.Equal(t, 400, a.Width)
+	assert.Equal(t, 200, a.Height)
+	require.NotNil(t, a.ThumbnailKey)
+	assert.Len(t, b.keys, 2)
+
+	id, variant, expires, sig := signedQuery(t, a.ThumbnailURL)
+	assert.Equal(t, VariantThumbnail, variant)
+	_, content, err := s.Open(context.Background(), id, variant, expires, sig)
+	require.NoError(t, err)
+	defer content.Close()
+	thumb, err := jpeg.Decode(content)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 32), thumb.Bounds())
+
+	text, err := s.Upload(context.Background(), 1, 1, "notes.txt", bytes.NewReader([]byte("plain notes")))
+	require.NoError(t, err)
+	assert.Nil(t, text.ThumbnailKey)
+	assert.Empty(t, text.ThumbnailURL)
+	assert.Len(t, b.keys, 3)
+}
+
+func TestUploadRemovesBlobsWhenStorageFails(t *testing.T) {
+	s, st, b := newService()
+	st.err = apperror.ForeignKeyViolation
+	_, err := s.Upload(context.Background(), 1, 1, "photo.png", bytes.NewReader(encodePNG(t, 10, 10)))
+	require.ErrorIs(t, err, apperror.ForeignKeyViolation)
+	assert.Empty(t, b.keys)
+}
+
+func TestSignedURL(t *testing.T) {
+	s, _, _ := newService()
+	a, err := s.Upload(context.Background(), 1, 1, "notes.txt", bytes.NewReader([]byte("plain notes")))
+	require.NoError(t, err)
+	id, variant, expires, sig := signedQuery(t, a.URL)
+
+	_, content, err := s.Open(context.Background(), id, variant, expires, sig)
+	require.NoError(t, err)
+	body, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "plain notes", string(body))
+
+	tests := []struct {
+		name    string
+		id      int
+		variant string
+		expires int64
+		sig     string
+	}{
+		{name: "other id", id: id + 1, variant: variant, expires: expires, sig: sig},
+		{name: "other variant", id: id, variant: VariantThumbnail, expires: expires, sig: sig},
+		{name: "extended expiry", id: id, variant: variant, expires: expires + 3600, sig: sig},
+		{name: "tampered signature", id: id, variant: variant, expires: expires, sig: tamper(sig)},
+		{name: "no signature", id: id, variant: variant, expires: expires},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.Open(context.Background(), tt.id, tt.variant, tt.expires, tt.sig)
+			assert.ErrorIs(t, err, apperror.InvalidSignedURL)
+		})
+	}
+
+	now := time.Now()
+	assert.True(t, s.verify(id, variant, expires, sig, time.Unix(expires, 0)))
+	assert.False(t, s.verify(id, variant, expires, sig, time.Unix(expires+1, 0)), "expired")
+	other := *s
+	other.options.SigningKey = []byte("another-key")
+	assert.False(t, other.verify(id, variant, expires, sig, now), "signed with another key")
+}
+
+func TestOnlyTheOwnerChangesAttachments(t *testing.T) {
+	s, st, b := newService()
+	_, err := s.Upload(context.Background(), 1, 2, "notes.txt", bytes.NewReader([]byte("plain notes")))
+	require.ErrorIs(t, err, apperror.ProductNotOwner)
+	assert.Empty(t, b.keys)
+
+	a, err := s.Upload(context.Background(), 1, 1, "notes.txt", bytes.NewReader([]byte("plain notes")))
+	require.NoError(t, err)
+	require.ErrorIs(t, s.Delete(context.Background(), a.ID, 2), apperror.ProductNotOwner)
+	assert.Len(t, st.attachments, 1)
+	assert.Len(t, b.keys, 1)
+
+	require.NoError(t, s.Delete(context.Background(), a.ID, 1))
+	assert.Empty(t, st.attachments)
+	assert.Empty(t, b.keys)
+}
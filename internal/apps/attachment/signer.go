@@ -0,0 +1,34 @@
+package attachment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func (s *attachmentService) signature(id int, variant string, expires int64) string {
+	mac := hmac.New(sha256.New, s.options.SigningKey)
+	mac.Write([]byte(fmt.Sprintf("%d:%s:%d", id, variant, expires)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *attachmentService) signURL(id int, variant string, now time.Time) string {
+	expires := now.Add(s.options.URLTTL).Unix()
+	query := url.Values{}
+	query.Set("id", strconv.Itoa(id))
+	query.Set("variant", variant)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signature(id, variant, expires))
+	return s.options.DownloadURL + "?" + query.Encode()
+}
+
+func (s *attachmentService) verify(id int, variant string, expires int64, sig string, now time.Time) bool {
+	if now.Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(s.signature(id, variant, expires)))
+}
@@ -0,0 +1,10 @@
+package attachment
+
+import "context"
+
+type Storage interface {
+	Create(ctx context.Context, attachmentDTO CreateAttachmentDTO) (a *Attachment, err error)
+	FindOne(ctx context.Context, id int) (a *Attachment, err error)
+	FindProductAttachments(ctx context.Context, productId int) ([]Attachment, error)
+	Delete(ctx context.Context, id int) error
+}
@@ -0,0 +1,41 @@
+package attachment
+
+import (
+	"context"
+	"go.mod/pkg/tracing"
+	"io"
+)
+
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps s so that every call is recorded as a child span of
+// the span carried by its context.
+func NewTracedService(s Service) Service {
+	return &tracedService{next: s}
+}
+
+func (t *tracedService) Upload(ctx context.Context, productId, userId int, filename string, r io.Reader) (a *Attachment, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Service/Upload")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Upload(ctx, productId, userId, filename, r)
+}
+
+func (t *tracedService) FindProductAttachments(ctx context.Context, productId int) (result []Attachment, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Service/FindProductAttachments")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindProductAttachments(ctx, productId)
+}
+
+func (t *tracedService) Open(ctx context.Context, id int, variant string, expires int64, sig string) (a *Attachment, content io.ReadCloser, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Service/Open")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Open(ctx, id, variant, expires, sig)
+}
+
+func (t *tracedService) Delete(ctx context.Context, id, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Service/Delete")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Delete(ctx, id, userId)
+}
@@ -0,0 +1,90 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql"
+)
+
+// ListCacheKey is the key FindAll is cached under. Writes publish the keys
+// they affect to invalidate the entries on every instance.
+const ListCacheKey = "category:all"
+
+type cachedService struct {
+	next  Service
+	cache *cache.ReadThrough
+}
+
+// NewCachedService serves lookups by id and title and the category list from
+// c, and drops the affected entries whenever s changes a category. Misses load
+// from the primary database so that a lagging replica is never cached.
+func NewCachedService(s Service, c *cache.ReadThrough) Service {
+	return &cachedService{next: s, cache: c}
+}
+
+// IdCacheKey is the key FindOneById caches a category under.
+func IdCacheKey(id int) string {
+	return fmt.Sprintf("category:id:%d", id)
+}
+
+// TitleCacheKey is the key FindOneByTitle caches a category under.
+func TitleCacheKey(title string) string {
+	return "category:title:" + title
+}
+
+func (c *cachedService) Create(ctx context.Context, createUser CreateUpdateCategory) (u *Category, err error) {
+	created, err := c.next.Create(ctx, createUser)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.Invalidate(IdCacheKey(created.Id), TitleCacheKey(created.Title), ListCacheKey)
+	return created, nil
+}
+
+// Delete looks the category up first, its title entry has to go as well.
+func (c *cachedService) Delete(ctx context.Context, id int) error {
+	keys := []string{IdCacheKey(id), ListCacheKey}
+	if existing, err := c.next.FindOneById(ctx, id); err == nil {
+		keys = append(keys, TitleCacheKey(existing.Title))
+	}
+	defer c.cache.Invalidate(keys...)
+	return c.next.Delete(ctx, id)
+}
+
+func (c *cachedService) Update(ctx context.Context, updateDTO CreateUpdateCategory, categoryDTO Category) (u *Category, err error) {
+	defer c.cache.Invalidate(IdCacheKey(categoryDTO.Id), TitleCacheKey(categoryDTO.Title), TitleCacheKey(updateDTO.Title), ListCacheKey)
+	return c.next.Update(ctx, updateDTO, categoryDTO)
+}
+
+func (c *cachedService) FindAll(ctx context.Context) (u []Category, err error) {
+	err = c.cache.Fetch(ctx, ListCacheKey, &u, func(ctx context.Context) (interface{}, error) {
+		return c.next.FindAll(postgresql.WithPrimary(ctx))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (c *cachedService) FindOneById(ctx context.Context, id int) (u *Category, err error) {
+	var found Category
+	err = c.cache.Fetch(ctx, IdCacheKey(id), &found, func(ctx context.Context) (interface{}, error) {
+		return c.next.FindOneById(postgresql.WithPrimary(ctx), id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (c *cachedService) FindOneByTitle(ctx context.Context, title string) (u *Category, err error) {
+	var found Category
+	err = c.cache.Fetch(ctx, TitleCacheKey(title), &found, func(ctx context.Context) (interface{}, error) {
+		return c.next.FindOneByTitle(postgresql.WithPrimary(ctx), title)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
@@ -3,104 +3,108 @@ package db
 import (
 	"context"
 	"fmt"
-	"github.com/jackc/pgconn"
 	"go.mod/internal/apperror"
 	"go.mod/internal/apps/category"
+	"go.mod/pkg/cache"
 	"go.mod/pkg/client/postgresql"
 	"go.mod/pkg/logging"
 	"go.mod/pkg/utils"
 )
 
 type categoryRepository struct {
-	client postgresql.Client
-	logger *logging.Logger
+	client    postgresql.Client
+	publisher cache.Publisher
+	logger    *logging.Logger
 }
 
-func NewCategoryRepository(client postgresql.Client, logger *logging.Logger) category.Storage {
+// NewCategoryRepository publishes the cache keys of every category it
+// changes to publisher.
+func NewCategoryRepository(client postgresql.Client, publisher cache.Publisher, logger *logging.Logger) category.Storage {
 	return &categoryRepository{
-		client: client,
-		logger: logger,
+		client:    client,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// invalidate publishes the keys of cached entries a write made stale. The
+// write stands when publishing fails, the entries then expire with their TTL.
+func (r *categoryRepository) invalidate(ctx context.Context, keys ...string) {
+	if err := r.publisher.Publish(ctx, keys...); err != nil {
+		logging.FromContext(ctx).Warnf("publish cache invalidation: %v", err)
 	}
 }
 
 func (r *categoryRepository) FindOne(ctx context.Context, id int) (c *category.Category, err error) {
+	ctx = postgresql.Named(ctx, "category", "FindOne")
 	q := `
 	SELECT id, title, child_id FROM public.category WHERE id = $1
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	var categoryDTO category.Category
 	if err := r.client.QueryRow(ctx, q, id).Scan(&categoryDTO.Id, &categoryDTO.Title, &categoryDTO.ChildId); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			return nil, newErr
-		}
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
 	return &categoryDTO, nil
 }
 func (r *categoryRepository) FindOneByTitle(ctx context.Context, title string) (c *category.Category, err error) {
+	ctx = postgresql.Named(ctx, "category", "FindOneByTitle")
 	q := `
 	SELECT id, title, child_id FROM public.category WHERE title = $1
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	var categoryDTO category.Category
 	if err := r.client.QueryRow(ctx, q, title).Scan(&categoryDTO.Id, &categoryDTO.Title, &categoryDTO.ChildId); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			return nil, newErr
-		}
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
 	return &categoryDTO, nil
 }
 
 func (r *categoryRepository) FindAll(ctx context.Context) (c []category.Category, err error) {
+	ctx = postgresql.Named(ctx, "category", "FindAll")
 	q := `
 	SELECT id, title, child_id FROM public.category
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	query, err := r.client.Query(ctx, q)
 	if err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
+	defer query.Close()
 	categories := make([]category.Category, 0)
 
 	for query.Next() {
 		var categoryInfo category.Category
 		err := query.Scan(&categoryInfo.Id, &categoryInfo.Title, &categoryInfo.ChildId)
 		if err != nil {
-			return nil, err
+			return nil, apperror.FromPostgres(err)
 		}
 		categories = append(categories, categoryInfo)
 
 	}
 	if err = query.Err(); err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
-	return categories, err
+	return categories, nil
 }
 
 func (r *categoryRepository) Create(ctx context.Context, categoryDTO category.CreateUpdateCategory) (c *category.Category, err error) {
+	ctx = postgresql.Named(ctx, "category", "Create")
 	q := `
 	INSERT INTO public.category (title, child_id) VALUES ($1, $2) RETURNING id, title, child_id 
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	var categoryInfo category.Category
 	if err := r.client.QueryRow(ctx, q, categoryDTO.Title, categoryDTO.ChildId).
-		Scan(&categoryInfo.Id, &categoryDTO.Title, &categoryDTO.ChildId); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			if pgErr.Code == "23505" {
-				return nil, apperror.CategoryTileAlreadyExist
-			}
-			return nil, newErr
-		}
-		return nil, err
+		Scan(&categoryInfo.Id, &categoryInfo.Title, &categoryInfo.ChildId); err != nil {
+		return nil, apperror.FromPostgres(err, apperror.CategoryTileAlreadyExist)
 	}
+	r.invalidate(ctx, category.IdCacheKey(categoryInfo.Id), category.TitleCacheKey(categoryInfo.Title), category.ListCacheKey)
 	return &categoryInfo, nil
 }
 
 func (r *categoryRepository) Update(ctx context.Context, categoryUpdate category.CreateUpdateCategory, categoryDTO category.Category) (c *category.Category, err error) {
+	ctx = postgresql.Named(ctx, "category", "Update")
 	q := `
 	UPDATE public.category 
 	SET title=$1, child_id = $2
@@ -112,30 +116,24 @@ func (r *categoryRepository) Update(ctx context.Context, categoryUpdate category
 	    FOR UPDATE 
 	)
 	RETURNING id, title, child_id;`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	previousTitle := categoryDTO.Title
 	if err := r.client.QueryRow(ctx, q, categoryUpdate.Title, categoryUpdate.ChildId, categoryDTO.Id).Scan(&categoryDTO.Id, &categoryDTO.Title, &categoryDTO.ChildId); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			if pgErr.Code == "23505" {
-				return nil, apperror.CategoryTileAlreadyExist
-			}
-			return nil, newErr
-		}
-		return nil, err
+		return nil, apperror.FromPostgres(err, apperror.CategoryTileAlreadyExist)
 	}
-	return nil, err
+	r.invalidate(ctx, category.IdCacheKey(categoryDTO.Id), category.TitleCacheKey(previousTitle), category.TitleCacheKey(categoryDTO.Title), category.ListCacheKey)
+	return &categoryDTO, nil
 }
 
 func (r *categoryRepository) Delete(ctx context.Context, id int) error {
+	ctx = postgresql.Named(ctx, "category", "Delete")
 	q := `	
-	DELETE FROM public.category WHERE id = $1;`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
-	if err := r.client.QueryRow(ctx, q, id).Scan(&id); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			return newErr
-		}
-		return err
+	DELETE FROM public.category WHERE id = $1 RETURNING title;`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	var title string
+	if err := r.client.QueryRow(ctx, q, id).Scan(&title); err != nil {
+		return apperror.FromPostgres(err)
 	}
+	r.invalidate(ctx, category.IdCacheKey(id), category.TitleCacheKey(title), category.ListCacheKey)
 	return nil
 }
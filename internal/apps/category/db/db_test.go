@@ -0,0 +1,17 @@
+package db_test
+
+import (
+	"go.mod/internal/apps/category"
+	"go.mod/internal/apps/category/db"
+	"go.mod/internal/apps/category/storagetest"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql/pgtest"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+func TestCategoryRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) category.Storage {
+		return db.NewCategoryRepository(pgtest.Client(t), cache.NopPublisher, logging.GetLogger())
+	})
+}
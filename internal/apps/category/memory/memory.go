@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/category"
+	"sort"
+	"sync"
+)
+
+// categoryRepository keeps categories in a map with the unique title of the
+// Postgres schema.
+type categoryRepository struct {
+	mu         sync.RWMutex
+	categories map[int]category.Category
+	nextID     int
+}
+
+func NewCategoryRepository() category.Storage {
+	return &categoryRepository{categories: make(map[int]category.Category)}
+}
+
+func (r *categoryRepository) taken(id int, title string) bool {
+	for _, c := range r.categories {
+		if c.Id != id && c.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *categoryRepository) FindOne(ctx context.Context, id int) (c *category.Category, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stored, ok := r.categories[id]
+	if !ok {
+		return nil, apperror.ErrorNotFound
+	}
+	return &stored, nil
+}
+
+func (r *categoryRepository) FindOneByTitle(ctx context.Context, title string) (c *category.Category, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, stored := range r.categories {
+		if stored.Title == title {
+			return &stored, nil
+		}
+	}
+	return nil, apperror.ErrorNotFound
+}
+
+func (r *categoryRepository) FindAll(ctx context.Context) (c []category.Category, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	categories := make([]category.Category, 0, len(r.categories))
+	for _, stored := range r.categories {
+		categories = append(categories, stored)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Id < categories[j].Id })
+	return categories, nil
+}
+
+func (r *categoryRepository) Create(ctx context.Context, categoryDTO category.CreateUpdateCategory) (c *category.Category, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.taken(0, categoryDTO.Title) {
+		return nil, apperror.CategoryTileAlreadyExist
+	}
+	r.nextID++
+	created := category.Category{Id: r.nextID, Title: categoryDTO.Title, ChildId: categoryDTO.ChildId}
+	r.categories[created.Id] = created
+	return &created, nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, categoryUpdate category.CreateUpdateCategory, categoryDTO category.Category) (c *category.Category, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.categories[categoryDTO.Id]
+	if !ok {
+		return nil, apperror.ErrorNotFound
+	}
+	if r.taken(stored.Id, categoryUpdate.Title) {
+		return nil, apperror.CategoryTileAlreadyExist
+	}
+	stored.Title = categoryUpdate.Title
+	stored.ChildId = categoryUpdate.ChildId
+	r.categories[stored.Id] = stored
+	return &stored, nil
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.categories[id]; !ok {
+		return apperror.ErrorNotFound
+	}
+	delete(r.categories, id)
+	return nil
+}
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"go.mod/internal/apps/category"
+	"go.mod/internal/apps/category/memory"
+	"go.mod/internal/apps/category/storagetest"
+	"testing"
+)
+
+func TestCategoryRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) category.Storage {
+		return memory.NewCategoryRepository()
+	})
+}
@@ -0,0 +1,131 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/category"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/mongodb"
+	"go.mod/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collection = "categories"
+
+type categoryDocument struct {
+	Id      int    `bson:"_id"`
+	Title   string `bson:"title"`
+	ChildId int    `bson:"child_id"`
+}
+
+type categoryRepository struct {
+	db         *mongo.Database
+	categories *mongo.Collection
+	publisher  cache.Publisher
+	logger     *logging.Logger
+}
+
+// NewCategoryRepository returns a category.Storage keeping categories in
+// MongoDB with a unique index on the title. Writes publish the cache keys
+// they make stale to publisher.
+func NewCategoryRepository(ctx context.Context, db *mongo.Database, publisher cache.Publisher, logger *logging.Logger) (category.Storage, error) {
+	categories := db.Collection(collection)
+	_, err := categories.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "title", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s indexes: %w", collection, err)
+	}
+	return &categoryRepository{
+		db:         db,
+		categories: categories,
+		publisher:  publisher,
+		logger:     logger,
+	}, nil
+}
+
+// invalidate publishes the keys of cached entries a write made stale. The
+// write stands when publishing fails, the entries then expire with their TTL.
+func (r *categoryRepository) invalidate(ctx context.Context, keys ...string) {
+	if err := r.publisher.Publish(ctx, keys...); err != nil {
+		logging.FromContext(ctx).Warnf("publish cache invalidation: %v", err)
+	}
+}
+
+func (r *categoryRepository) FindOne(ctx context.Context, id int) (c *category.Category, err error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *categoryRepository) FindOneByTitle(ctx context.Context, title string) (c *category.Category, err error) {
+	return r.findOne(ctx, bson.M{"title": title})
+}
+
+func (r *categoryRepository) findOne(ctx context.Context, filter bson.M) (*category.Category, error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOne %v", collection, filter))
+	var document categoryDocument
+	if err := r.categories.FindOne(ctx, filter).Decode(&document); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	found := category.Category(document)
+	return &found, nil
+}
+
+func (r *categoryRepository) FindAll(ctx context.Context) (c []category.Category, err error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.find", collection))
+	cursor, err := r.categories.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	var documents []categoryDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	categories := make([]category.Category, 0, len(documents))
+	for _, document := range documents {
+		categories = append(categories, category.Category(document))
+	}
+	return categories, nil
+}
+
+func (r *categoryRepository) Create(ctx context.Context, categoryDTO category.CreateUpdateCategory) (c *category.Category, err error) {
+	id, err := mongodb.NextID(ctx, r.db, collection)
+	if err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	document := categoryDocument{Id: id, Title: categoryDTO.Title, ChildId: categoryDTO.ChildId}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.insertOne %d", collection, id))
+	if _, err := r.categories.InsertOne(ctx, document); err != nil {
+		return nil, apperror.FromMongo(err, apperror.CategoryTileAlreadyExist)
+	}
+	r.invalidate(ctx, category.IdCacheKey(id), category.TitleCacheKey(document.Title), category.ListCacheKey)
+	created := category.Category(document)
+	return &created, nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, categoryUpdate category.CreateUpdateCategory, categoryDTO category.Category) (c *category.Category, err error) {
+	filter := bson.M{"_id": categoryDTO.Id}
+	update := bson.M{"$set": bson.M{"title": categoryUpdate.Title, "child_id": categoryUpdate.ChildId}}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOneAndUpdate %v", collection, filter))
+	var previous categoryDocument
+	err = r.categories.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&previous)
+	if err != nil {
+		return nil, apperror.FromMongo(err, apperror.CategoryTileAlreadyExist)
+	}
+	updated := category.Category{Id: previous.Id, Title: categoryUpdate.Title, ChildId: categoryUpdate.ChildId}
+	r.invalidate(ctx, category.IdCacheKey(updated.Id), category.TitleCacheKey(previous.Title), category.TitleCacheKey(updated.Title), category.ListCacheKey)
+	return &updated, nil
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id int) error {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOneAndDelete %d", collection, id))
+	var deleted categoryDocument
+	if err := r.categories.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&deleted); err != nil {
+		return apperror.FromMongo(err)
+	}
+	r.invalidate(ctx, category.IdCacheKey(id), category.TitleCacheKey(deleted.Title), category.ListCacheKey)
+	return nil
+}
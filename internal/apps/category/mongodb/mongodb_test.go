@@ -0,0 +1,22 @@
+package mongodb_test
+
+import (
+	"context"
+	"go.mod/internal/apps/category"
+	"go.mod/internal/apps/category/mongodb"
+	"go.mod/internal/apps/category/storagetest"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/mongodb/mongotest"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+func TestCategoryRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) category.Storage {
+		storage, err := mongodb.NewCategoryRepository(context.Background(), mongotest.Database(t), cache.NopPublisher, logging.GetLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return storage
+	})
+}
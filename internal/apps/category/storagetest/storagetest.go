@@ -0,0 +1,112 @@
+// Package storagetest is the contract every category.Storage backend must pass.
+package storagetest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/category"
+	"testing"
+)
+
+// Run checks the storage returned by newStorage, which must be empty and is
+// called once per subtest.
+func Run(t *testing.T, newStorage func(t *testing.T) category.Storage) {
+	ctx := context.Background()
+	create := func(t *testing.T, s category.Storage, title string) *category.Category {
+		t.Helper()
+		c, err := s.Create(ctx, category.CreateUpdateCategory{Title: title})
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		s := newStorage(t)
+		books := create(t, s, "books")
+		assert.NotZero(t, books.Id)
+		assert.Equal(t, "books", books.Title)
+
+		child, err := s.Create(ctx, category.CreateUpdateCategory{Title: "novels", ChildId: books.Id})
+		require.NoError(t, err)
+		assert.NotEqual(t, books.Id, child.Id)
+		assert.Equal(t, books.Id, child.ChildId)
+	})
+
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		s := newStorage(t)
+		create(t, s, "books")
+		_, err := s.Create(ctx, category.CreateUpdateCategory{Title: "books"})
+		assert.ErrorIs(t, err, apperror.CategoryTileAlreadyExist)
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		s := newStorage(t)
+		books := create(t, s, "books")
+
+		found, err := s.FindOne(ctx, books.Id)
+		require.NoError(t, err)
+		assert.Equal(t, *books, *found)
+
+		byTitle, err := s.FindOneByTitle(ctx, "books")
+		require.NoError(t, err)
+		assert.Equal(t, *books, *byTitle)
+	})
+
+	t.Run("FindNotFound", func(t *testing.T) {
+		s := newStorage(t)
+		_, err := s.FindOne(ctx, 4242)
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+		_, err = s.FindOneByTitle(ctx, "nothing")
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		s := newStorage(t)
+		all, err := s.FindAll(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, all)
+		assert.Empty(t, all)
+
+		books, music := create(t, s, "books"), create(t, s, "music")
+		all, err = s.FindAll(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []category.Category{*books, *music}, all)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStorage(t)
+		books := create(t, s, "books")
+
+		updated, err := s.Update(ctx, category.CreateUpdateCategory{Title: "ebooks", ChildId: 7}, *books)
+		require.NoError(t, err)
+		assert.Equal(t, category.Category{Id: books.Id, Title: "ebooks", ChildId: 7}, *updated)
+
+		_, err = s.FindOneByTitle(ctx, "books")
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+	})
+
+	t.Run("UpdateDuplicate", func(t *testing.T) {
+		s := newStorage(t)
+		create(t, s, "books")
+		music := create(t, s, "music")
+		_, err := s.Update(ctx, category.CreateUpdateCategory{Title: "books"}, *music)
+		assert.ErrorIs(t, err, apperror.CategoryTileAlreadyExist)
+	})
+
+	t.Run("UpdateNotFound", func(t *testing.T) {
+		s := newStorage(t)
+		_, err := s.Update(ctx, category.CreateUpdateCategory{Title: "books"}, category.Category{Id: 4242})
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStorage(t)
+		books := create(t, s, "books")
+
+		require.NoError(t, s.Delete(ctx, books.Id))
+		_, err := s.FindOne(ctx, books.Id)
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+		assert.ErrorIs(t, s.Delete(ctx, books.Id), apperror.ErrorNotFound)
+	})
+}
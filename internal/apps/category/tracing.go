@@ -0,0 +1,52 @@
+package category
+
+import (
+	"context"
+	"go.mod/pkg/tracing"
+)
+
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps s so that every call is recorded as a child span of
+// the span carried by its context.
+func NewTracedService(s Service) Service {
+	return &tracedService{next: s}
+}
+
+func (t *tracedService) Create(ctx context.Context, createUser CreateUpdateCategory) (u *Category, err error) {
+	ctx, span := tracing.Start(ctx, "category.Service/Create")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Create(ctx, createUser)
+}
+
+func (t *tracedService) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "category.Service/Delete")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Delete(ctx, id)
+}
+
+func (t *tracedService) Update(ctx context.Context, updateDTO CreateUpdateCategory, categoryDTO Category) (u *Category, err error) {
+	ctx, span := tracing.Start(ctx, "category.Service/Update")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Update(ctx, updateDTO, categoryDTO)
+}
+
+func (t *tracedService) FindAll(ctx context.Context) (u []Category, err error) {
+	ctx, span := tracing.Start(ctx, "category.Service/FindAll")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindAll(ctx)
+}
+
+func (t *tracedService) FindOneById(ctx context.Context, id int) (u *Category, err error) {
+	ctx, span := tracing.Start(ctx, "category.Service/FindOneById")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindOneById(ctx, id)
+}
+
+func (t *tracedService) FindOneByTitle(ctx context.Context, title string) (u *Category, err error) {
+	ctx, span := tracing.Start(ctx, "category.Service/FindOneByTitle")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindOneByTitle(ctx, title)
+}
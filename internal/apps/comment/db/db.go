@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/comment"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/logging"
+	"go.mod/pkg/utils"
+)
+
+const commentColumns = `id, product_id, parent_id, author_id, body, status, created_at, updated_at, deleted_at`
+
+type commentRepository struct {
+	client postgresql.Client
+	logger *logging.Logger
+}
+
+func NewCommentRepository(client postgresql.Client, logger *logging.Logger) comment.Storage {
+	return &commentRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func scanComment(row pgx.Row, c *comment.Comment) error {
+	return row.Scan(&c.ID, &c.ProductId, &c.ParentId, &c.AuthorId, &c.Body, &c.Status, &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt)
+}
+
+func (r *commentRepository) queryRow(ctx context.Context, q string, args ...interface{}) (*comment.Comment, error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	var commentObj comment.Comment
+	if err := scanComment(r.client.QueryRow(ctx, q, args...), &commentObj); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return &commentObj, nil
+}
+
+func (r *commentRepository) query(ctx context.Context, q string, args ...interface{}) ([]comment.Comment, error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	query, err := r.client.Query(ctx, q, args...)
+	if err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	defer query.Close()
+
+	comments := make([]comment.Comment, 0)
+	for query.Next() {
+		var commentInfo comment.Comment
+		if err := scanComment(query, &commentInfo); err != nil {
+			return nil, apperror.FromPostgres(err)
+		}
+		comments = append(comments, commentInfo)
+	}
+	if err = query.Err(); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) Create(ctx context.Context, commentDTO comment.CreateCommentDTO) (c *comment.Comment, err error) {
+	ctx = postgresql.Named(ctx, "comment", "Create")
+	q := `
+	INSERT INTO public.comment (product_id, parent_id, author_id, body, status)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING ` + commentColumns
+	return r.queryRow(ctx, q, commentDTO.ProductId, commentDTO.ParentId, commentDTO.AuthorId, commentDTO.Body, comment.StatusPending)
+}
+
+func (r *commentRepository) FindOne(ctx context.Context, id int) (c *comment.Comment, err error) {
+	ctx = postgresql.Named(ctx, "comment", "FindOne")
+	q := `SELECT ` + commentColumns + ` FROM public.comment WHERE id = $1`
+	return r.queryRow(ctx, q, id)
+}
+
+func (r *commentRepository) FindProductComments(ctx context.Context, productId int) (c []comment.Comment, err error) {
+	ctx = postgresql.Named(ctx, "comment", "FindProductComments")
+	q := `
+	SELECT ` + commentColumns + `
+	FROM public.comment
+	WHERE product_id = $1
+	ORDER BY created_at, id`
+	return r.query(ctx, q, productId)
+}
+
+func (r *commentRepository) FindByStatus(ctx context.Context, status string) (c []comment.Comment, err error) {
+	ctx = postgresql.Named(ctx, "comment", "FindByStatus")
+	q := `
+	SELECT ` + commentColumns + `
+	FROM public.comment
+	WHERE status = $1 AND deleted_at IS NULL
+	ORDER BY created_at, id`
+	return r.query(ctx, q, status)
+}
+
+func (r *commentRepository) Update(ctx context.Context, commentObj comment.Comment, commentUpdate comment.UpdateCommentDTO) (c *comment.Comment, err error) {
+	ctx = postgresql.Named(ctx, "comment", "Update")
+	q := `
+	UPDATE public.comment
+	SET body = $1, status = $3, updated_at = now()
+	WHERE id = (
+	    SELECT id
+	    FROM public.comment
+	    WHERE id = $2
+	    AND deleted_at IS NULL
+	    LIMIT 1
+	    FOR UPDATE
+	)
+	RETURNING ` + commentColumns
+	return r.queryRow(ctx, q, commentUpdate.Body, commentObj.ID, commentObj.Status)
+}
+
+func (r *commentRepository) SetStatus(ctx context.Context, id int, status string) (c *comment.Comment, err error) {
+	ctx = postgresql.Named(ctx, "comment", "SetStatus")
+	q := `
+	UPDATE public.comment
+	SET status = $1, updated_at = now()
+	WHERE id = $2
+	RETURNING ` + commentColumns
+	return r.queryRow(ctx, q, status, id)
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id int) error {
+	ctx = postgresql.Named(ctx, "comment", "Delete")
+	q := `
+	UPDATE public.comment SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL
+	`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	tag, err := r.client.Exec(ctx, q, id)
+	if err != nil {
+		return apperror.FromPostgres(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrorNotFound
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package comment
+
+import "time"
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// DeletedPlaceholder replaces the body of a soft deleted comment so that
+// its replies still have a parent to hang on.
+const DeletedPlaceholder = "[deleted]"
+
+// EditWindow is how long after creation the author may still edit a comment.
+const EditWindow = 15 * time.Minute
+
+type CreateCommentDTO struct {
+	ProductId int  `json:"product_id"`
+	ParentId  *int `json:"parent_id,omitempty"`
+	// AuthorId is the authenticated user, it is never read from the body.
+	AuthorId int    `json:"-"`
+	Body     string `json:"body"`
+}
+
+// Actor is the authenticated user changing a comment. Moderators may delete
+// any comment, only authors may edit theirs. A body edited by an author who
+// is not a moderator needs a new approval.
+type Actor struct {
+	UserId    int
+	Moderator bool
+}
+
+type UpdateCommentDTO struct {
+	Body string `json:"body"`
+}
+
+type ModerateCommentDTO struct {
+	Status string `json:"status"`
+}
+
+type Comment struct {
+	ID        int        `json:"id"`
+	ProductId int        `json:"product_id"`
+	ParentId  *int       `json:"parent_id,omitempty"`
+	AuthorId  int        `json:"author_id,omitempty"`
+	Body      string     `json:"body"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"-"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	Replies   []*Comment `json:"replies,omitempty"`
+}
+
+func (c *Comment) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// CanEdit reports whether the comment is still inside its edit window.
+func (c *Comment) CanEdit(now time.Time) bool {
+	return now.Sub(c.CreatedAt) <= EditWindow
+}
+
+// Hide masks a deleted or not yet visible comment while keeping its place in the thread.
+func (c *Comment) Hide() {
+	c.Body = DeletedPlaceholder
+	c.AuthorId = 0
+	c.Deleted = true
+}
+
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusApproved, StatusRejected:
+		return true
+	}
+	return false
+}
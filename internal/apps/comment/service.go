@@ -0,0 +1,172 @@
+package comment
+
+import (
+	"context"
+	"go.mod/internal/apperror"
+	"go.mod/pkg/logging"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	Create(ctx context.Context, commentDTO CreateCommentDTO) (c *Comment, err error)
+	Update(ctx context.Context, comment Comment, commentUpdate UpdateCommentDTO, actor Actor) (c *Comment, err error)
+	Delete(ctx context.Context, id int, actor Actor) error
+	Moderate(ctx context.Context, id int, status string) (c *Comment, err error)
+	FindOneById(ctx context.Context, id int, viewer Actor) (c *Comment, err error)
+	FindProductThread(ctx context.Context, productId int) ([]*Comment, error)
+	FindPending(ctx context.Context) ([]Comment, error)
+}
+
+type commentService struct {
+	storage Storage
+	logger  *logging.Logger
+}
+
+func NewService(storage Storage, logger *logging.Logger) Service {
+	return &commentService{
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+func (s *commentService) Create(ctx context.Context, commentDTO CreateCommentDTO) (c *Comment, err error) {
+	if strings.TrimSpace(commentDTO.Body) == "" {
+		return nil, apperror.BadRequestError("comment body is required")
+	}
+	if commentDTO.ParentId != nil {
+		parent, err := s.storage.FindOne(ctx, *commentDTO.ParentId)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ProductId != commentDTO.ProductId {
+			return nil, apperror.CommentParentMismatch
+		}
+		if parent.IsDeleted() {
+			return nil, apperror.CommentAlreadyDeleted
+		}
+	}
+	created, err := s.storage.Create(ctx, commentDTO)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *commentService) Update(ctx context.Context, comment Comment, commentUpdate UpdateCommentDTO, actor Actor) (c *Comment, err error) {
+	if comment.IsDeleted() {
+		return nil, apperror.CommentAlreadyDeleted
+	}
+	if comment.AuthorId != actor.UserId {
+		return nil, apperror.CommentNotAuthor
+	}
+	if !comment.CanEdit(time.Now()) {
+		return nil, apperror.CommentEditWindowExpired
+	}
+	if strings.TrimSpace(commentUpdate.Body) == "" {
+		return nil, apperror.BadRequestError("comment body is required")
+	}
+	// an approved comment must not be rewritten into something a moderator
+	// never saw, so a changed body goes back to the moderation queue
+	if commentUpdate.Body != comment.Body && !actor.Moderator {
+		comment.Status = StatusPending
+	}
+	updated, err := s.storage.Update(ctx, comment, commentUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *commentService) Delete(ctx context.Context, id int, actor Actor) error {
+	one, err := s.storage.FindOne(ctx, id)
+	if err != nil {
+		return err
+	}
+	if one.AuthorId != actor.UserId && !actor.Moderator {
+		return apperror.CommentNotAuthor
+	}
+	return s.storage.Delete(ctx, id)
+}
+
+func (s *commentService) Moderate(ctx context.Context, id int, status string) (c *Comment, err error) {
+	if !IsValidStatus(status) {
+		return nil, apperror.InvalidCommentStatus
+	}
+	moderated, err := s.storage.SetStatus(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+	return moderated, nil
+}
+
+// FindOneById masks a comment the way its thread does, a pending or rejected
+// comment is only shown as is to its author and to moderators.
+func (s *commentService) FindOneById(ctx context.Context, id int, viewer Actor) (c *Comment, err error) {
+	one, err := s.storage.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if one.IsDeleted() || (one.Status != StatusApproved && one.AuthorId != viewer.UserId && !viewer.Moderator) {
+		one.Hide()
+	}
+	return one, nil
+}
+
+func (s *commentService) FindProductThread(ctx context.Context, productId int) ([]*Comment, error) {
+	all, err := s.storage.FindProductComments(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	return buildThread(all), nil
+}
+
+func (s *commentService) FindPending(ctx context.Context) ([]Comment, error) {
+	pending, err := s.storage.FindByStatus(ctx, StatusPending)
+	if err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// buildThread turns a flat, creation ordered list of comments into reply trees.
+// Deleted or unapproved comments are kept as "[deleted]" placeholders only when
+// at least one visible reply hangs below them, otherwise they are dropped.
+// Replies always stay under their parent, a hidden parent never turns them
+// into roots.
+func buildThread(comments []Comment) []*Comment {
+	nodes := make(map[int]*Comment, len(comments))
+	for i := range comments {
+		nodes[comments[i].ID] = &comments[i]
+	}
+
+	roots := make([]*Comment, 0)
+	for i := range comments {
+		node := &comments[i]
+		if node.ParentId != nil {
+			if parent, ok := nodes[*node.ParentId]; ok {
+				parent.Replies = append(parent.Replies, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return prune(roots)
+}
+
+// prune works bottom up so that a parent is only dropped once none of its
+// replies is left to show.
+func prune(comments []*Comment) []*Comment {
+	visible := make([]*Comment, 0, len(comments))
+	for _, c := range comments {
+		c.Replies = prune(c.Replies)
+		if c.IsDeleted() || c.Status != StatusApproved {
+			if len(c.Replies) == 0 {
+				continue
+			}
+			c.Hide()
+		}
+		visible = append(visible, c)
+	}
+	return visible
+}
@@ -0,0 +1,239 @@
+package comment
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/pkg/logging"
+	"testing"
+	"time"
+)
+
+// storage keeps comments by id, only what the service tests need.
+type storage struct {
+	Storage
+	comments map[int]*Comment
+}
+
+func (s *storage) FindOne(_ context.Context, id int) (*Comment, error) {
+	c, ok := s.comments[id]
+	if !ok {
+		return nil, apperror.ErrorNotFound
+	}
+	one := *c
+	return &one, nil
+}
+
+func (s *storage) Update(_ context.Context, c Comment, update UpdateCommentDTO) (*Comment, error) {
+	c.Body = update.Body
+	s.comments[c.ID] = &c
+	return &c, nil
+}
+
+func (s *storage) Delete(_ context.Context, id int) error {
+	now := time.Now()
+	s.comments[id].DeletedAt = &now
+	return nil
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// ids flattens a thread depth first, marking placeholders with a minus sign.
+func ids(thread []*Comment) []int {
+	var flat []int
+	for _, c := range thread {
+		id := c.ID
+		if c.Deleted {
+			id = -id
+		}
+		flat = append(flat, id)
+		flat = append(flat, ids(c.Replies)...)
+	}
+	return flat
+}
+
+func TestBuildThread(t *testing.T) {
+	deletedAt := time.Now()
+	tests := []struct {
+		name     string
+		comments []Comment
+		want     []int
+	}{
+		{
+			name: "nested replies",
+			comments: []Comment{
+				{ID: 1, Status: StatusApproved},
+				{ID: 2, ParentId: intPtr(1), Status: StatusApproved},
+				{ID: 3, Status: StatusApproved},
+				{ID: 4, ParentId: intPtr(2), Status: StatusApproved},
+			},
+			want: []int{1, 2, 4, 3},
+		},
+		{
+			name: "hidden leaves are dropped",
+			comments: []Comment{
+				{ID: 1, Status: StatusApproved},
+				{ID: 2, ParentId: intPtr(1), Status: StatusPending},
+				{ID: 3, Status: StatusRejected},
+				{ID: 4, Status: StatusApproved, DeletedAt: &deletedAt},
+			},
+			want: []int{1},
+		},
+		{
+			name: "hidden parents of visible replies stay as placeholders",
+			comments: []Comment{
+				{ID: 1, Status: StatusApproved, DeletedAt: &deletedAt},
+				{ID: 2, ParentId: intPtr(1), Status: StatusApproved},
+				{ID: 3, Status: StatusPending},
+				{ID: 4, ParentId: intPtr(3), Status: StatusApproved},
+			},
+			want: []int{-1, 2, -3, 4},
+		},
+		{
+			name: "a visible grandchild keeps every hidden ancestor",
+			comments: []Comment{
+				{ID: 1, Status: StatusRejected},
+				{ID: 2, ParentId: intPtr(1), Status: StatusApproved, DeletedAt: &deletedAt},
+				{ID: 3, ParentId: intPtr(2), Status: StatusPending},
+				{ID: 4, ParentId: intPtr(3), Status: StatusApproved},
+				{ID: 5, ParentId: intPtr(1), Status: StatusPending},
+			},
+			want: []int{-1, -2, -3, 4},
+		},
+		{
+			name: "replies to an unknown parent become roots",
+			comments: []Comment{
+				{ID: 2, ParentId: intPtr(1), Status: StatusApproved},
+			},
+			want: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ids(buildThread(tt.comments)))
+		})
+	}
+}
+
+func TestPruneHidesPlaceholders(t *testing.T) {
+	thread := buildThread([]Comment{
+		{ID: 1, AuthorId: 7, Body: "secret", Status: StatusPending},
+		{ID: 2, ParentId: intPtr(1), AuthorId: 8, Body: "reply", Status: StatusApproved},
+	})
+	require.Len(t, thread, 1)
+	require.Equal(t, DeletedPlaceholder, thread[0].Body)
+	require.Zero(t, thread[0].AuthorId)
+	require.Len(t, thread[0].Replies, 1)
+	require.Equal(t, "reply", thread[0].Replies[0].Body)
+}
+
+func TestUpdateEditWindow(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		actor     Actor
+		want      error
+	}{
+		{name: "author inside the window", createdAt: now.Add(-EditWindow + time.Minute), actor: Actor{UserId: 1}},
+		{name: "author after the window", createdAt: now.Add(-EditWindow - time.Second), actor: Actor{UserId: 1}, want: apperror.CommentEditWindowExpired},
+		{name: "another user", createdAt: now, actor: Actor{UserId: 2}, want: apperror.CommentNotAuthor},
+		{name: "moderators do not edit", createdAt: now, actor: Actor{UserId: 2, Moderator: true}, want: apperror.CommentNotAuthor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Comment{ID: 1, AuthorId: 1, Body: "old", Status: StatusApproved, CreatedAt: tt.createdAt}
+			s := NewService(&storage{comments: map[int]*Comment{1: &c}}, logging.GetLogger())
+			updated, err := s.Update(context.Background(), c, UpdateCommentDTO{Body: "new"}, tt.actor)
+			if tt.want != nil {
+				require.ErrorIs(t, err, tt.want)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "new", updated.Body)
+		})
+	}
+
+	deletedAt := now
+	deleted := Comment{ID: 1, AuthorId: 1, CreatedAt: now, DeletedAt: &deletedAt}
+	s := NewService(&storage{comments: map[int]*Comment{1: &deleted}}, logging.GetLogger())
+	_, err := s.Update(context.Background(), deleted, UpdateCommentDTO{Body: "new"}, Actor{UserId: 1})
+	require.ErrorIs(t, err, apperror.CommentAlreadyDeleted)
+}
+
+func TestUpdateResetsApproval(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		actor Actor
+		want  string
+	}{
+		{name: "changed body", body: "new", actor: Actor{UserId: 1}, want: StatusPending},
+		{name: "unchanged body", body: "old", actor: Actor{UserId: 1}, want: StatusApproved},
+		{name: "moderator author", body: "new", actor: Actor{UserId: 1, Moderator: true}, want: StatusApproved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Comment{ID: 1, AuthorId: 1, Body: "old", Status: StatusApproved, CreatedAt: time.Now()}
+			st := &storage{comments: map[int]*Comment{1: &c}}
+			updated, err := NewService(st, logging.GetLogger()).Update(context.Background(), c, UpdateCommentDTO{Body: tt.body}, tt.actor)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, updated.Status)
+			require.Equal(t, tt.want, st.comments[1].Status)
+		})
+	}
+}
+
+func TestFindOneByIdHidesUnapproved(t *testing.T) {
+	deletedAt := time.Now()
+	tests := []struct {
+		name   string
+		status string
+		viewer Actor
+		hidden bool
+	}{
+		{name: "approved", status: StatusApproved, viewer: Actor{}},
+		{name: "pending for anonymous readers", status: StatusPending, viewer: Actor{}, hidden: true},
+		{name: "rejected for other users", status: StatusRejected, viewer: Actor{UserId: 2}, hidden: true},
+		{name: "pending for its author", status: StatusPending, viewer: Actor{UserId: 1}},
+		{name: "rejected for moderators", status: StatusRejected, viewer: Actor{UserId: 2, Moderator: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &storage{comments: map[int]*Comment{1: {ID: 1, AuthorId: 1, Body: "hi", Status: tt.status}}}
+			one, err := NewService(st, logging.GetLogger()).FindOneById(context.Background(), 1, tt.viewer)
+			require.NoError(t, err)
+			require.Equal(t, tt.hidden, one.Deleted)
+			if tt.hidden {
+				require.Equal(t, DeletedPlaceholder, one.Body)
+				require.Zero(t, one.AuthorId)
+			}
+		})
+	}
+
+	st := &storage{comments: map[int]*Comment{1: {ID: 1, AuthorId: 1, Status: StatusApproved, DeletedAt: &deletedAt}}}
+	one, err := NewService(st, logging.GetLogger()).FindOneById(context.Background(), 1, Actor{UserId: 1, Moderator: true})
+	require.NoError(t, err)
+	require.True(t, one.Deleted, "deleted comments are hidden from everyone")
+}
+
+func TestDeleteRequiresAuthorOrModerator(t *testing.T) {
+	newService := func() (Service, *storage) {
+		st := &storage{comments: map[int]*Comment{1: {ID: 1, AuthorId: 1}}}
+		return NewService(st, logging.GetLogger()), st
+	}
+
+	s, st := newService()
+	require.ErrorIs(t, s.Delete(context.Background(), 1, Actor{UserId: 2}), apperror.CommentNotAuthor)
+	require.False(t, st.comments[1].IsDeleted())
+	require.ErrorIs(t, s.Delete(context.Background(), 9, Actor{UserId: 1}), apperror.ErrorNotFound)
+
+	require.NoError(t, s.Delete(context.Background(), 1, Actor{UserId: 1}))
+	require.True(t, st.comments[1].IsDeleted())
+
+	s, st = newService()
+	require.NoError(t, s.Delete(context.Background(), 1, Actor{UserId: 2, Moderator: true}))
+	require.True(t, st.comments[1].IsDeleted())
+}
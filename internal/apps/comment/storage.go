@@ -0,0 +1,14 @@
+package comment
+
+import "context"
+
+type Storage interface {
+	Create(ctx context.Context, commentDTO CreateCommentDTO) (c *Comment, err error)
+	FindOne(ctx context.Context, id int) (c *Comment, err error)
+	FindProductComments(ctx context.Context, productId int) (c []Comment, err error)
+	FindByStatus(ctx context.Context, status string) (c []Comment, err error)
+	// Update stores the body of commentUpdate and the status of comment.
+	Update(ctx context.Context, comment Comment, commentUpdate UpdateCommentDTO) (c *Comment, err error)
+	SetStatus(ctx context.Context, id int, status string) (c *Comment, err error)
+	Delete(ctx context.Context, id int) error
+}
@@ -0,0 +1,58 @@
+package comment
+
+import (
+	"context"
+	"go.mod/pkg/tracing"
+)
+
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps s so that every call is recorded as a child span of
+// the span carried by its context.
+func NewTracedService(s Service) Service {
+	return &tracedService{next: s}
+}
+
+func (t *tracedService) Create(ctx context.Context, commentDTO CreateCommentDTO) (c *Comment, err error) {
+	ctx, span := tracing.Start(ctx, "comment.Service/Create")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Create(ctx, commentDTO)
+}
+
+func (t *tracedService) Update(ctx context.Context, comment Comment, commentUpdate UpdateCommentDTO, actor Actor) (c *Comment, err error) {
+	ctx, span := tracing.Start(ctx, "comment.Service/Update")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Update(ctx, comment, commentUpdate, actor)
+}
+
+func (t *tracedService) Delete(ctx context.Context, id int, actor Actor) (err error) {
+	ctx, span := tracing.Start(ctx, "comment.Service/Delete")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Delete(ctx, id, actor)
+}
+
+func (t *tracedService) Moderate(ctx context.Context, id int, status string) (c *Comment, err error) {
+	ctx, span := tracing.Start(ctx, "comment.Service/Moderate")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Moderate(ctx, id, status)
+}
+
+func (t *tracedService) FindOneById(ctx context.Context, id int, viewer Actor) (c *Comment, err error) {
+	ctx, span := tracing.Start(ctx, "comment.Service/FindOneById")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindOneById(ctx, id, viewer)
+}
+
+func (t *tracedService) FindProductThread(ctx context.Context, productId int) (result []*Comment, err error) {
+	ctx, span := tracing.Start(ctx, "comment.Service/FindProductThread")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindProductThread(ctx, productId)
+}
+
+func (t *tracedService) FindPending(ctx context.Context) (result []Comment, err error) {
+	ctx, span := tracing.Start(ctx, "comment.Service/FindPending")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindPending(ctx)
+}
@@ -0,0 +1,80 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql"
+)
+
+type cachedService struct {
+	next  Service
+	cache *cache.ReadThrough
+}
+
+// NewCachedService serves FindOneById from c and drops the entry of a product
+// whenever s changes it. Changes made elsewhere, by other instances or to the
+// reaction counters, reach c through the repositories publishing CacheKey.
+// Misses load from the primary database, a replica still behind the write that
+// caused an invalidation would get its stale row cached until the TTL.
+func NewCachedService(s Service, c *cache.ReadThrough) Service {
+	return &cachedService{next: s, cache: c}
+}
+
+// CacheKey is the key a product is cached under, writes publish it to
+// invalidate the entry on every instance.
+func CacheKey(id int) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
+func (c *cachedService) Create(ctx context.Context, post CreateProductDTO) (*Product, error) {
+	created, err := c.next.Create(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+	// a lookup of the id before it existed may be remembered as not found
+	c.cache.Invalidate(CacheKey(created.ID))
+	return created, nil
+}
+
+func (c *cachedService) Delete(ctx context.Context, postId int) error {
+	defer c.cache.Invalidate(CacheKey(postId))
+	return c.next.Delete(ctx, postId)
+}
+
+func (c *cachedService) Update(ctx context.Context, post *Product, postUpdate UpdateProductDTO, authorId int) (u *Product, err error) {
+	defer c.cache.Invalidate(CacheKey(post.ID))
+	return c.next.Update(ctx, post, postUpdate, authorId)
+}
+
+func (c *cachedService) FindAll(ctx context.Context) ([]Product, error) {
+	return c.next.FindAll(ctx)
+}
+
+func (c *cachedService) FindOneById(ctx context.Context, id int) (u *Product, err error) {
+	var post Product
+	err = c.cache.Fetch(ctx, CacheKey(id), &post, func(ctx context.Context) (interface{}, error) {
+		return c.next.FindOneById(postgresql.WithPrimary(ctx), id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (c *cachedService) FindUserPosts(ctx context.Context, userId int) ([]Product, error) {
+	return c.next.FindUserPosts(ctx, userId)
+}
+
+func (c *cachedService) FindRevisions(ctx context.Context, id int) ([]Revision, error) {
+	return c.next.FindRevisions(ctx, id)
+}
+
+func (c *cachedService) Diff(ctx context.Context, id, from, to int) (*RevisionDiff, error) {
+	return c.next.Diff(ctx, id, from, to)
+}
+
+func (c *cachedService) Rollback(ctx context.Context, post *Product, version, authorId int) (u *Product, err error) {
+	defer c.cache.Invalidate(CacheKey(post.ID))
+	return c.next.Rollback(ctx, post, version, authorId)
+}
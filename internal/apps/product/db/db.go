@@ -2,147 +2,238 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"go.mod/internal/apperror"
 	"go.mod/internal/apps/product"
+	"go.mod/pkg/cache"
 	"go.mod/pkg/client/postgresql"
 	"go.mod/pkg/logging"
 	"go.mod/pkg/utils"
 )
 
+// countersColumns reads the denormalized reaction and bookmark counters kept in
+// public.product_counter instead of counting membership rows on every read.
+const countersColumns = `
+	COALESCE((SELECT jsonb_object_agg(c.kind, c.count) FROM public.product_counter c WHERE c.product_id = p.id AND c.kind <> 'bookmark'), '{}'),
+	COALESCE((SELECT c.count FROM public.product_counter c WHERE c.product_id = p.id AND c.kind = 'bookmark'), 0)`
+
 type ProductRepository struct {
-	client postgresql.Client
-	logger *logging.Logger
+	client    postgresql.Client
+	publisher cache.Publisher
+	logger    *logging.Logger
 }
 
-func NewProductRepository(client postgresql.Client, logger *logging.Logger) product.Storage {
+// NewProductRepository publishes the cache key of every product it changes
+// to publisher.
+func NewProductRepository(client postgresql.Client, publisher cache.Publisher, logger *logging.Logger) product.Storage {
 	return &ProductRepository{
-		client: client,
-		logger: logger,
+		client:    client,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// invalidate publishes the key of a product a write made stale. The write
+// stands when publishing fails, the entry then expires with its TTL.
+func (r *ProductRepository) invalidate(ctx context.Context, id int) {
+	if err := r.publisher.Publish(ctx, product.CacheKey(id)); err != nil {
+		logging.FromContext(ctx).Warnf("publish cache invalidation: %v", err)
 	}
 }
 
 func (r *ProductRepository) Create(ctx context.Context, ProductObj product.CreateProductDTO) (u *product.Product, err error) {
+	ctx = postgresql.Named(ctx, "product", "Create")
 	q := `
-	INSERT INTO public.product (title, description, owner_id) VALUES ($1, $2, $3) RETURNING id, title, description, owner_id
+	WITH created AS (
+	    INSERT INTO public.product (title, description, owner_id) VALUES ($1, $2, $3) RETURNING id, title, description, owner_id, version
+	), revision AS (
+	    INSERT INTO public.product_revision (product_id, version, title, description, author_id)
+	    SELECT id, version, title, description, owner_id FROM created
+	)
+	SELECT id, title, description, owner_id, version FROM created
 	`
 
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	var ProductDTO product.Product
-	if err := r.client.QueryRow(ctx, q, ProductObj.Title, ProductObj.Description, ProductObj.OwnerId).Scan(&ProductDTO.ID, &ProductDTO.Title, &ProductDTO.Description, &ProductDTO.OwnerId); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			if pgErr.Code == "23505" {
-				return nil, apperror.ProductTitleAlreadyExist
-			}
-			return nil, newErr
-		}
-		return nil, err
+	if err := r.client.QueryRow(ctx, q, ProductObj.Title, ProductObj.Description, ProductObj.OwnerId).Scan(&ProductDTO.ID, &ProductDTO.Title, &ProductDTO.Description, &ProductDTO.OwnerId, &ProductDTO.Version); err != nil {
+		return nil, apperror.FromPostgres(err, apperror.ProductTitleAlreadyExist)
 	}
+	r.invalidate(ctx, ProductDTO.ID)
 	return &ProductDTO, nil
 }
 
-func (r *ProductRepository) Update(ctx context.Context, ProductObj *product.Product, ProductUpdate product.UpdateProductDTO) (u *product.Product, err error) {
+func (r *ProductRepository) Update(ctx context.Context, ProductObj *product.Product, ProductUpdate product.UpdateProductDTO, authorId int) (u *product.Product, err error) {
+	ctx = postgresql.Named(ctx, "product", "Update")
+	return r.update(ctx, ProductObj, ProductUpdate.Title, ProductUpdate.Description, authorId, nil)
+}
+
+func (r *ProductRepository) Rollback(ctx context.Context, ProductObj *product.Product, revision product.Revision, authorId int) (u *product.Product, err error) {
+	ctx = postgresql.Named(ctx, "product", "Rollback")
+	return r.update(ctx, ProductObj, revision.Title, revision.Description, authorId, &revision.Version)
+}
+
+// update bumps the product version and stores the new content as a revision
+// row in the same statement, so a product never changes without its history.
+// It only applies when the row is still at ProductObj.Version.
+func (r *ProductRepository) update(ctx context.Context, ProductObj *product.Product, title, description string, authorId int, rollbackOf *int) (u *product.Product, err error) {
 	q := `
-		UPDATE public.product 
-		SET title = $1, description = $2 
-		WHERE id = (
-			SELECT id
-			FROM public.product
-			WHERE id = $3
-			AND owner_id = $4
-			LIMIT 1
-			FOR UPDATE 
+		WITH updated AS (
+			UPDATE public.product 
+			SET title = $1, description = $2, version = version + 1
+			WHERE id = (
+				SELECT id
+				FROM public.product
+				WHERE id = $3
+				AND owner_id = $4
+				AND version = $7
+				LIMIT 1
+				FOR UPDATE 
+			)
+			RETURNING id, title, description, version
+		), revision AS (
+			INSERT INTO public.product_revision (product_id, version, title, description, author_id, rollback_of)
+			SELECT id, version, title, description, $5, $6 FROM updated
 		)
-	RETURNING title, description;`
+	SELECT title, description, version FROM updated;`
 
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 
-	if err := r.client.QueryRow(ctx, q, ProductUpdate.Title, ProductUpdate.Description, ProductObj.ID, ProductObj.OwnerId).Scan(&ProductObj.Title, &ProductObj.Description); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			if pgErr.Code == "23505" {
-				return nil, apperror.ProductTitleAlreadyExist
-			}
-			return nil, newErr
+	if err := r.client.QueryRow(ctx, q, title, description, ProductObj.ID, ProductObj.OwnerId, authorId, rollbackOf, ProductObj.Version).Scan(&ProductObj.Title, &ProductObj.Description, &ProductObj.Version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.PreconditionFailed
 		}
-		return nil, err
+		return nil, apperror.FromPostgres(err, apperror.ProductTitleAlreadyExist)
 	}
+	r.invalidate(ctx, ProductObj.ID)
 	return ProductObj, nil
 }
 
 func (r *ProductRepository) FindOne(ctx context.Context, id int) (u *product.Product, err error) {
-	q := `SELECT id, title, description, owner_id FROM public.product WHERE id = $1`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	ctx = postgresql.Named(ctx, "product", "FindOne")
+	q := `SELECT p.id, p.title, p.description, p.owner_id, p.version, ` + countersColumns + ` FROM public.product p WHERE p.id = $1`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	var ProductObj product.Product
-	if err := r.client.QueryRow(ctx, q, id).Scan(&ProductObj.ID, &ProductObj.Title, &ProductObj.Description, &ProductObj.OwnerId); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			return nil, newErr
-		}
-		r.logger.Debug(err)
-		return nil, err
+	if err := r.client.QueryRow(ctx, q, id).Scan(&ProductObj.ID, &ProductObj.Title, &ProductObj.Description, &ProductObj.OwnerId, &ProductObj.Version, &ProductObj.Reactions, &ProductObj.Bookmarks); err != nil {
+		return nil, apperror.FromPostgres(err)
 	}
 	return &ProductObj, nil
 }
 
 func (r *ProductRepository) FindAll(ctx context.Context) (u []product.Product, err error) {
-	q := `SELECT id, title, description, owner_id FROM public.product`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	ctx = postgresql.Named(ctx, "product", "FindAll")
+	q := `SELECT p.id, p.title, p.description, p.owner_id, p.version, ` + countersColumns + ` FROM public.product p`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	query, err := r.client.Query(ctx, q)
 	if err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
+	defer query.Close()
 
 	Products := make([]product.Product, 0)
-	r.logger.Debug(query)
+	logging.FromContext(ctx).Debug(query)
 	for query.Next() {
 		var ProductInfo product.Product
-		err := query.Scan(&ProductInfo.ID, &ProductInfo.Title, &ProductInfo.Description, &ProductInfo.OwnerId)
+		err := query.Scan(&ProductInfo.ID, &ProductInfo.Title, &ProductInfo.Description, &ProductInfo.OwnerId, &ProductInfo.Version, &ProductInfo.Reactions, &ProductInfo.Bookmarks)
 		if err != nil {
-			return nil, err
+			return nil, apperror.FromPostgres(err)
 		}
 
 		Products = append(Products, ProductInfo)
 	}
+	if err = query.Err(); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
 	return Products, nil
 }
 
 func (r *ProductRepository) FindUserAllProducts(ctx context.Context, userId int) ([]product.Product, error) {
+	ctx = postgresql.Named(ctx, "product", "FindUserAllProducts")
 	q := `
-			SELECT id, title, description, owner_id FROM public.product WHERE owner_id = $1
+			SELECT p.id, p.title, p.description, p.owner_id, p.version, ` + countersColumns + ` FROM public.product p WHERE p.owner_id = $1
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 	query, err := r.client.Query(ctx, q, userId)
 	if err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
 	defer query.Close()
 
 	Products := make([]product.Product, 0)
 	for query.Next() {
 		var ProductInfo product.Product
-		err := query.Scan(&ProductInfo.ID, &ProductInfo.Title, &ProductInfo.Description, &ProductInfo.OwnerId)
+		err := query.Scan(&ProductInfo.ID, &ProductInfo.Title, &ProductInfo.Description, &ProductInfo.OwnerId, &ProductInfo.Version, &ProductInfo.Reactions, &ProductInfo.Bookmarks)
 		if err != nil {
-			return nil, err
+			return nil, apperror.FromPostgres(err)
 		}
 
 		Products = append(Products, ProductInfo)
 	}
+	if err = query.Err(); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
 	return Products, nil
 }
 
 func (r *ProductRepository) Delete(ctx context.Context, id int) error {
+	ctx = postgresql.Named(ctx, "product", "Delete")
 	q := `
 	DELETE FROM public.product WHERE id=$1
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
-	if err := r.client.QueryRow(ctx, q, id).Scan(&id); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			return newErr
-		}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	tag, err := r.client.Exec(ctx, q, id)
+	if err != nil {
+		return apperror.FromPostgres(err)
 	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrorNotFound
+	}
+	r.invalidate(ctx, id)
 	return nil
 }
+
+func (r *ProductRepository) FindRevisions(ctx context.Context, productId int) ([]product.Revision, error) {
+	ctx = postgresql.Named(ctx, "product", "FindRevisions")
+	q := `
+	SELECT product_id, version, title, description, COALESCE(author_id, 0), rollback_of, created_at
+	FROM public.product_revision
+	WHERE product_id = $1
+	ORDER BY version DESC
+	`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	query, err := r.client.Query(ctx, q, productId)
+	if err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	defer query.Close()
+
+	revisions := make([]product.Revision, 0)
+	for query.Next() {
+		var revision product.Revision
+		err := query.Scan(&revision.ProductId, &revision.Version, &revision.Title, &revision.Description, &revision.AuthorId, &revision.RollbackOf, &revision.CreatedAt)
+		if err != nil {
+			return nil, apperror.FromPostgres(err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err = query.Err(); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return revisions, nil
+}
+
+func (r *ProductRepository) FindRevision(ctx context.Context, productId, version int) (*product.Revision, error) {
+	ctx = postgresql.Named(ctx, "product", "FindRevision")
+	q := `
+	SELECT product_id, version, title, description, COALESCE(author_id, 0), rollback_of, created_at
+	FROM public.product_revision
+	WHERE product_id = $1 AND version = $2
+	`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	var revision product.Revision
+	if err := r.client.QueryRow(ctx, q, productId, version).Scan(&revision.ProductId, &revision.Version, &revision.Title, &revision.Description, &revision.AuthorId, &revision.RollbackOf, &revision.CreatedAt); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return &revision, nil
+}
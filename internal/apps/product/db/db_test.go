@@ -0,0 +1,43 @@
+package db_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apps/product"
+	"go.mod/internal/apps/product/db"
+	"go.mod/internal/apps/product/storagetest"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql/pgtest"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+func TestProductRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Fixture {
+		client := pgtest.Client(t)
+		return storagetest.Fixture{
+			Storage: db.NewProductRepository(client, cache.NopPublisher, logging.GetLogger()),
+			Owners:  [2]int{pgtest.CreateUser(t, client, "alice"), pgtest.CreateUser(t, client, "bob")},
+		}
+	})
+}
+
+func TestRevisionOutlivesItsAuthor(t *testing.T) {
+	ctx := context.Background()
+	client := pgtest.Client(t)
+	storage := db.NewProductRepository(client, cache.NopPublisher, logging.GetLogger())
+	owner, editor := pgtest.CreateUser(t, client, "alice"), pgtest.CreateUser(t, client, "bob")
+
+	p, err := storage.Create(ctx, product.CreateProductDTO{Title: "lamp", Description: "bright", OwnerId: owner})
+	require.NoError(t, err)
+	_, err = storage.Update(ctx, p, product.UpdateProductDTO{Title: "desk lamp", Description: "bright"}, editor)
+	require.NoError(t, err)
+	_, err = client.Exec(ctx, `DELETE FROM public.user WHERE id = $1`, editor)
+	require.NoError(t, err)
+
+	revisions, err := storage.FindRevisions(ctx, p.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Zero(t, revisions[0].AuthorId)
+	require.Equal(t, owner, revisions[1].AuthorId)
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProductRepository keeps products and their revisions in maps with the
+// unique title and the optimistic version checks of the Postgres schema.
+// Reaction and bookmark counters are always empty.
+type ProductRepository struct {
+	mu        sync.RWMutex
+	products  map[int]product.Product
+	revisions map[int][]product.Revision
+	nextID    int
+}
+
+func NewProductRepository() product.Storage {
+	return &ProductRepository{
+		products:  make(map[int]product.Product),
+		revisions: make(map[int][]product.Revision),
+	}
+}
+
+func (r *ProductRepository) taken(id int, title string) bool {
+	for _, p := range r.products {
+		if p.ID != id && p.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+// addRevision records the current content of p, newest first.
+func (r *ProductRepository) addRevision(p product.Product, authorId int, rollbackOf *int) {
+	revision := product.Revision{
+		ProductId:   p.ID,
+		Version:     p.Version,
+		Title:       p.Title,
+		Description: p.Description,
+		AuthorId:    authorId,
+		RollbackOf:  rollbackOf,
+		CreatedAt:   time.Now().UTC(),
+	}
+	r.revisions[p.ID] = append([]product.Revision{revision}, r.revisions[p.ID]...)
+}
+
+func (r *ProductRepository) Create(ctx context.Context, ProductObj product.CreateProductDTO) (u *product.Product, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.taken(0, ProductObj.Title) {
+		return nil, apperror.ProductTitleAlreadyExist
+	}
+	r.nextID++
+	created := product.Product{
+		ID:          r.nextID,
+		Title:       ProductObj.Title,
+		Description: ProductObj.Description,
+		OwnerId:     ProductObj.OwnerId,
+		Version:     1,
+	}
+	r.products[created.ID] = created
+	r.addRevision(created, created.OwnerId, nil)
+	return &created, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, ProductObj *product.Product, ProductUpdate product.UpdateProductDTO, authorId int) (u *product.Product, err error) {
+	return r.update(ProductObj, ProductUpdate.Title, ProductUpdate.Description, authorId, nil)
+}
+
+func (r *ProductRepository) Rollback(ctx context.Context, ProductObj *product.Product, revision product.Revision, authorId int) (u *product.Product, err error) {
+	return r.update(ProductObj, revision.Title, revision.Description, authorId, &revision.Version)
+}
+
+func (r *ProductRepository) update(ProductObj *product.Product, title, description string, authorId int, rollbackOf *int) (*product.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.products[ProductObj.ID]
+	if !ok || stored.OwnerId != ProductObj.OwnerId || stored.Version != ProductObj.Version {
+		return nil, apperror.PreconditionFailed
+	}
+	if r.taken(stored.ID, title) {
+		return nil, apperror.ProductTitleAlreadyExist
+	}
+	stored.Title, stored.Description = title, description
+	stored.Version++
+	r.products[stored.ID] = stored
+	r.addRevision(stored, authorId, rollbackOf)
+
+	ProductObj.Title, ProductObj.Description, ProductObj.Version = stored.Title, stored.Description, stored.Version
+	return ProductObj, nil
+}
+
+func (r *ProductRepository) FindOne(ctx context.Context, id int) (u *product.Product, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stored, ok := r.products[id]
+	if !ok {
+		return nil, apperror.ErrorNotFound
+	}
+	found := withCounters(stored)
+	return &found, nil
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context) (u []product.Product, err error) {
+	return r.find(func(product.Product) bool { return true }), nil
+}
+
+func (r *ProductRepository) FindUserAllProducts(ctx context.Context, userId int) ([]product.Product, error) {
+	return r.find(func(p product.Product) bool { return p.OwnerId == userId }), nil
+}
+
+func (r *ProductRepository) find(match func(product.Product) bool) []product.Product {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	products := make([]product.Product, 0)
+	for _, stored := range r.products {
+		if match(stored) {
+			products = append(products, withCounters(stored))
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products
+}
+
+// withCounters gives p the empty counters Postgres reads for a product
+// without reactions.
+func withCounters(p product.Product) product.Product {
+	p.Reactions = map[string]int{}
+	return p
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[id]; !ok {
+		return apperror.ErrorNotFound
+	}
+	delete(r.products, id)
+	delete(r.revisions, id)
+	return nil
+}
+
+func (r *ProductRepository) FindRevisions(ctx context.Context, productId int) ([]product.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append(make([]product.Revision, 0, len(r.revisions[productId])), r.revisions[productId]...), nil
+}
+
+func (r *ProductRepository) FindRevision(ctx context.Context, productId, version int) (*product.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, revision := range r.revisions[productId] {
+		if revision.Version == version {
+			return &revision, nil
+		}
+	}
+	return nil, apperror.ErrorNotFound
+}
@@ -0,0 +1,13 @@
+package memory_test
+
+import (
+	"go.mod/internal/apps/product/memory"
+	"go.mod/internal/apps/product/storagetest"
+	"testing"
+)
+
+func TestProductRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Fixture {
+		return storagetest.Fixture{Storage: memory.NewProductRepository(), Owners: [2]int{1, 2}}
+	})
+}
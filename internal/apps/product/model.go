@@ -1,5 +1,10 @@
 package product
 
+import (
+	"go.mod/pkg/diff"
+	"time"
+)
+
 type CreateProductDTO struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
@@ -13,9 +18,42 @@ type UpdateProductDTO struct {
 }
 
 type Product struct {
-	ID          int    `json:"id,omitempty"`
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	OwnerId     int    `json:"owner_id,omitempty"`
-	CategoryId  int    `json:"category_id,omitempty"`
+	ID          int            `json:"id,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description,omitempty"`
+	OwnerId     int            `json:"owner_id,omitempty"`
+	CategoryId  int            `json:"category_id,omitempty"`
+	Version     int            `json:"version,omitempty"`
+	Reactions   map[string]int `json:"reactions,omitempty"`
+	Bookmarks   int            `json:"bookmarks,omitempty"`
+}
+
+// Revision is an immutable snapshot of a product written on every change.
+// AuthorId is 0 once the author's account was deleted.
+type Revision struct {
+	ProductId   int       `json:"product_id"`
+	Version     int       `json:"version"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	AuthorId    int       `json:"author_id"`
+	RollbackOf  *int      `json:"rollback_of,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RevisionDiff struct {
+	ProductId   int         `json:"product_id"`
+	From        int         `json:"from"`
+	To          int         `json:"to"`
+	Title       []diff.Line `json:"title"`
+	Description []diff.Line `json:"description"`
+}
+
+func NewRevisionDiff(from, to Revision) RevisionDiff {
+	return RevisionDiff{
+		ProductId:   from.ProductId,
+		From:        from.Version,
+		To:          to.Version,
+		Title:       diff.Lines(from.Title, to.Title),
+		Description: diff.Lines(from.Description, to.Description),
+	}
 }
@@ -0,0 +1,286 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/mongodb"
+	"go.mod/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	collection          = "products"
+	revisionsCollection = "product_revisions"
+
+	// undoTimeout bounds undoing a change whose revision could not be
+	// written, the request context may well be why the write failed.
+	undoTimeout = 5 * time.Second
+)
+
+// productDocument is the stored form of a product. Reaction and bookmark
+// counters live with the reactions in Postgres, they are not part of it.
+type productDocument struct {
+	ID          int    `bson:"_id"`
+	Title       string `bson:"title"`
+	Description string `bson:"description"`
+	OwnerId     int    `bson:"owner_id"`
+	Version     int    `bson:"version"`
+}
+
+func (d productDocument) product() product.Product {
+	return product.Product{
+		ID:          d.ID,
+		Title:       d.Title,
+		Description: d.Description,
+		OwnerId:     d.OwnerId,
+		Version:     d.Version,
+		Reactions:   map[string]int{},
+	}
+}
+
+type revisionDocument struct {
+	ProductId   int       `bson:"product_id"`
+	Version     int       `bson:"version"`
+	Title       string    `bson:"title"`
+	Description string    `bson:"description"`
+	AuthorId    int       `bson:"author_id"`
+	RollbackOf  *int      `bson:"rollback_of,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+type ProductRepository struct {
+	db        *mongo.Database
+	products  *mongo.Collection
+	revisions *mongo.Collection
+	publisher cache.Publisher
+	logger    *logging.Logger
+}
+
+// NewProductRepository returns a product.Storage keeping products and their
+// revisions in MongoDB, with the unique title and revision indexes of the
+// Postgres schema. Without multi-document transactions a revision is written
+// right after the change it records, and the change is undone when that fails
+// so no version exists without its revision. Writes publish the cache key
+// of the product to publisher.
+func NewProductRepository(ctx context.Context, db *mongo.Database, publisher cache.Publisher, logger *logging.Logger) (product.Storage, error) {
+	r := &ProductRepository{
+		db:        db,
+		products:  db.Collection(collection),
+		revisions: db.Collection(revisionsCollection),
+		publisher: publisher,
+		logger:    logger,
+	}
+	_, err := r.products.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "title", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s indexes: %w", collection, err)
+	}
+	_, err = r.revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s indexes: %w", revisionsCollection, err)
+	}
+	return r, nil
+}
+
+func (r *ProductRepository) Create(ctx context.Context, ProductObj product.CreateProductDTO) (u *product.Product, err error) {
+	id, err := mongodb.NextID(ctx, r.db, collection)
+	if err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	document := productDocument{
+		ID:          id,
+		Title:       ProductObj.Title,
+		Description: ProductObj.Description,
+		OwnerId:     ProductObj.OwnerId,
+		Version:     1,
+	}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.insertOne %d", collection, id))
+	if _, err := r.products.InsertOne(ctx, document); err != nil {
+		return nil, apperror.FromMongo(err, apperror.ProductTitleAlreadyExist)
+	}
+	if err := r.addRevision(ctx, document, document.OwnerId, nil); err != nil {
+		r.undo(ctx, fmt.Sprintf("create of product %d", id), func(ctx context.Context) error {
+			_, err := r.products.DeleteOne(ctx, bson.M{"_id": id, "version": document.Version})
+			return err
+		})
+		return nil, err
+	}
+	r.invalidate(ctx, document.ID)
+	return &product.Product{
+		ID:          document.ID,
+		Title:       document.Title,
+		Description: document.Description,
+		OwnerId:     document.OwnerId,
+		Version:     document.Version,
+	}, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, ProductObj *product.Product, ProductUpdate product.UpdateProductDTO, authorId int) (u *product.Product, err error) {
+	return r.update(ctx, ProductObj, ProductUpdate.Title, ProductUpdate.Description, authorId, nil)
+}
+
+func (r *ProductRepository) Rollback(ctx context.Context, ProductObj *product.Product, revision product.Revision, authorId int) (u *product.Product, err error) {
+	return r.update(ctx, ProductObj, revision.Title, revision.Description, authorId, &revision.Version)
+}
+
+// update bumps the product version and records the new content as a
+// revision. It only applies when the product is still at ProductObj.Version
+// and owned by ProductObj.OwnerId.
+func (r *ProductRepository) update(ctx context.Context, ProductObj *product.Product, title, description string, authorId int, rollbackOf *int) (u *product.Product, err error) {
+	filter := bson.M{"_id": ProductObj.ID, "owner_id": ProductObj.OwnerId, "version": ProductObj.Version}
+	update := bson.M{
+		"$set": bson.M{"title": title, "description": description},
+		"$inc": bson.M{"version": 1},
+	}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOneAndUpdate %v", collection, filter))
+	var document productDocument
+	err = r.products.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&document)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.PreconditionFailed
+		}
+		return nil, apperror.FromMongo(err, apperror.ProductTitleAlreadyExist)
+	}
+	if err := r.addRevision(ctx, document, authorId, rollbackOf); err != nil {
+		r.undo(ctx, fmt.Sprintf("update of product %d", document.ID), func(ctx context.Context) error {
+			_, err := r.products.UpdateOne(ctx,
+				bson.M{"_id": document.ID, "version": document.Version},
+				bson.M{"$set": bson.M{"title": ProductObj.Title, "description": ProductObj.Description, "version": ProductObj.Version}},
+			)
+			return err
+		})
+		return nil, err
+	}
+	r.invalidate(ctx, document.ID)
+	ProductObj.Title, ProductObj.Description, ProductObj.Version = document.Title, document.Description, document.Version
+	return ProductObj, nil
+}
+
+// invalidate publishes the key of a product a write made stale. The write
+// stands when publishing fails, the entry then expires with its TTL.
+func (r *ProductRepository) invalidate(ctx context.Context, id int) {
+	if err := r.publisher.Publish(ctx, product.CacheKey(id)); err != nil {
+		logging.FromContext(ctx).Warnf("publish cache invalidation: %v", err)
+	}
+}
+
+// undo reverts a change whose revision could not be written. It runs on its
+// own deadline, a failure is only logged as the write already failed.
+func (r *ProductRepository) undo(ctx context.Context, change string, revert func(ctx context.Context) error) {
+	undoCtx, cancel := context.WithTimeout(context.Background(), undoTimeout)
+	defer cancel()
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: undo %s", change))
+	if err := revert(undoCtx); err != nil {
+		logging.FromContext(ctx).Errorf("undo %s without revision: %v", change, err)
+	}
+}
+
+func (r *ProductRepository) addRevision(ctx context.Context, document productDocument, authorId int, rollbackOf *int) error {
+	revision := revisionDocument{
+		ProductId:   document.ID,
+		Version:     document.Version,
+		Title:       document.Title,
+		Description: document.Description,
+		AuthorId:    authorId,
+		RollbackOf:  rollbackOf,
+		CreatedAt:   time.Now().UTC(),
+	}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.insertOne %d@%d", revisionsCollection, document.ID, document.Version))
+	if _, err := r.revisions.InsertOne(ctx, revision); err != nil {
+		return apperror.FromMongo(err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) FindOne(ctx context.Context, id int) (u *product.Product, err error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOne %d", collection, id))
+	var document productDocument
+	if err := r.products.FindOne(ctx, bson.M{"_id": id}).Decode(&document); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	found := document.product()
+	return &found, nil
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context) (u []product.Product, err error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *ProductRepository) FindUserAllProducts(ctx context.Context, userId int) ([]product.Product, error) {
+	return r.find(ctx, bson.M{"owner_id": userId})
+}
+
+func (r *ProductRepository) find(ctx context.Context, filter bson.M) ([]product.Product, error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.find %v", collection, filter))
+	cursor, err := r.products.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	var documents []productDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	products := make([]product.Product, 0, len(documents))
+	for _, document := range documents {
+		products = append(products, document.product())
+	}
+	return products, nil
+}
+
+// Delete removes the product with its revisions, as the cascade of the
+// Postgres schema does.
+func (r *ProductRepository) Delete(ctx context.Context, id int) error {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.deleteOne %d", collection, id))
+	result, err := r.products.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return apperror.FromMongo(err)
+	}
+	if result.DeletedCount == 0 {
+		return apperror.ErrorNotFound
+	}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.deleteMany %d", revisionsCollection, id))
+	r.invalidate(ctx, id)
+	if _, err := r.revisions.DeleteMany(ctx, bson.M{"product_id": id}); err != nil {
+		return apperror.FromMongo(err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) FindRevisions(ctx context.Context, productId int) ([]product.Revision, error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.find %d", revisionsCollection, productId))
+	cursor, err := r.revisions.Find(ctx, bson.M{"product_id": productId}, options.Find().SetSort(bson.M{"version": -1}))
+	if err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	var documents []revisionDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	revisions := make([]product.Revision, 0, len(documents))
+	for _, document := range documents {
+		revisions = append(revisions, product.Revision(document))
+	}
+	return revisions, nil
+}
+
+func (r *ProductRepository) FindRevision(ctx context.Context, productId, version int) (*product.Revision, error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOne %d@%d", revisionsCollection, productId, version))
+	var document revisionDocument
+	if err := r.revisions.FindOne(ctx, bson.M{"product_id": productId, "version": version}).Decode(&document); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	revision := product.Revision(document)
+	return &revision, nil
+}
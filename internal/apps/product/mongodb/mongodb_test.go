@@ -0,0 +1,55 @@
+package mongodb_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"go.mod/internal/apps/product/mongodb"
+	"go.mod/internal/apps/product/storagetest"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/mongodb/mongotest"
+	"go.mod/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestProductRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Fixture {
+		storage, err := mongodb.NewProductRepository(context.Background(), mongotest.Database(t), cache.NopPublisher, logging.GetLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return storagetest.Fixture{Storage: storage, Owners: [2]int{1, 2}}
+	})
+}
+
+// TestProductRepositoryUndoesChangesWithoutRevision occupies the revision a
+// write is about to record, so recording it fails.
+func TestProductRepositoryUndoesChangesWithoutRevision(t *testing.T) {
+	ctx := context.Background()
+	database := mongotest.Database(t)
+	storage, err := mongodb.NewProductRepository(ctx, database, cache.NopPublisher, logging.GetLogger())
+	require.NoError(t, err)
+	revisions := database.Collection("product_revisions")
+
+	created, err := storage.Create(ctx, product.CreateProductDTO{Title: "lamp", Description: "bright", OwnerId: 1})
+	require.NoError(t, err)
+
+	_, err = revisions.InsertOne(ctx, bson.M{"product_id": created.ID, "version": 2})
+	require.NoError(t, err)
+	_, err = storage.Update(ctx, created, product.UpdateProductDTO{Title: "desk lamp", Description: "brighter"}, created.OwnerId)
+	require.Error(t, err)
+	found, err := storage.FindOne(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "lamp", found.Title, "the update is undone")
+	assert.Equal(t, 1, found.Version)
+
+	_, err = revisions.InsertOne(ctx, bson.M{"product_id": created.ID + 1, "version": 1})
+	require.NoError(t, err)
+	_, err = storage.Create(ctx, product.CreateProductDTO{Title: "desk", Description: "wide", OwnerId: 1})
+	require.Error(t, err)
+	_, err = storage.FindOne(ctx, created.ID+1)
+	assert.ErrorIs(t, err, apperror.ErrorNotFound, "the create is undone")
+}
@@ -2,26 +2,32 @@ package product
 
 import (
 	"context"
+	"go.mod/pkg/client/postgresql"
 	"go.mod/pkg/logging"
 )
 
 type Service interface {
 	Create(ctx context.Context, post CreateProductDTO) (*Product, error)
 	Delete(ctx context.Context, postId int) error
-	Update(ctx context.Context, post *Product, postUpdate UpdateProductDTO) (u *Product, err error)
+	Update(ctx context.Context, post *Product, postUpdate UpdateProductDTO, authorId int) (u *Product, err error)
 	FindAll(ctx context.Context) ([]Product, error)
 	FindOneById(ctx context.Context, id int) (u *Product, err error)
 	FindUserPosts(ctx context.Context, userId int) ([]Product, error)
+	FindRevisions(ctx context.Context, id int) ([]Revision, error)
+	Diff(ctx context.Context, id, from, to int) (*RevisionDiff, error)
+	Rollback(ctx context.Context, post *Product, version, authorId int) (u *Product, err error)
 }
 
 type postService struct {
 	storage Storage
+	tx      postgresql.Transactor
 	logger  *logging.Logger
 }
 
-func NewService(storage Storage, logger *logging.Logger) Service {
+func NewService(storage Storage, tx postgresql.Transactor, logger *logging.Logger) Service {
 	return &postService{
 		storage: storage,
+		tx:      tx,
 		logger:  logger,
 	}
 }
@@ -42,8 +48,8 @@ func (s *postService) Delete(ctx context.Context, postId int) error {
 	return nil
 }
 
-func (s *postService) Update(ctx context.Context, post *Product, postUpdate UpdateProductDTO) (u *Product, err error) {
-	updated, err := s.storage.Update(ctx, post, postUpdate)
+func (s *postService) Update(ctx context.Context, post *Product, postUpdate UpdateProductDTO, authorId int) (u *Product, err error) {
+	updated, err := s.storage.Update(ctx, post, postUpdate, authorId)
 	if err != nil {
 		return nil, err
 	}
@@ -73,3 +79,42 @@ func (s *postService) FindUserPosts(ctx context.Context, userId int) ([]Product,
 	}
 	return posts, nil
 }
+
+func (s *postService) FindRevisions(ctx context.Context, id int) ([]Revision, error) {
+	revisions, err := s.storage.FindRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *postService) Diff(ctx context.Context, id, from, to int) (*RevisionDiff, error) {
+	fromRevision, err := s.storage.FindRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.storage.FindRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	revisionDiff := NewRevisionDiff(*fromRevision, *toRevision)
+	return &revisionDiff, nil
+}
+
+// Rollback restores the title and description of an older revision over
+// post, which fails like Update when post is no longer the current version.
+// The restore is stored as a new revision, so history is never rewritten.
+func (s *postService) Rollback(ctx context.Context, post *Product, version, authorId int) (u *Product, err error) {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		revision, err := s.storage.FindRevision(ctx, post.ID, version)
+		if err != nil {
+			return err
+		}
+		u, err = s.storage.Rollback(ctx, post, *revision, authorId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -9,6 +9,9 @@ type Storage interface {
 	FindOne(ctx context.Context, id int) (u *Product, err error)
 	FindAll(ctx context.Context) (u []Product, err error)
 	FindUserAllProducts(ctx context.Context, userId int) ([]Product, error)
-	Update(ctx context.Context, postObj *Product, postUpdate UpdateProductDTO) (u *Product, err error)
+	Update(ctx context.Context, postObj *Product, postUpdate UpdateProductDTO, authorId int) (u *Product, err error)
 	Delete(ctx context.Context, id int) error
+	FindRevisions(ctx context.Context, productId int) ([]Revision, error)
+	FindRevision(ctx context.Context, productId, version int) (r *Revision, err error)
+	Rollback(ctx context.Context, postObj *Product, revision Revision, authorId int) (u *Product, err error)
 }
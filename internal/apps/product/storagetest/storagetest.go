@@ -0,0 +1,188 @@
+// Package storagetest is the contract every product.Storage backend must pass.
+package storagetest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"testing"
+)
+
+// Fixture is an empty storage and two users that may own products.
+type Fixture struct {
+	Storage product.Storage
+	Owners  [2]int
+}
+
+// Run checks the fixtures returned by setup, which is called once per subtest.
+func Run(t *testing.T, setup func(t *testing.T) Fixture) {
+	ctx := context.Background()
+	create := func(t *testing.T, s product.Storage, title string, owner int) *product.Product {
+		t.Helper()
+		p, err := s.Create(ctx, product.CreateProductDTO{Title: title, Description: "about " + title, OwnerId: owner})
+		require.NoError(t, err)
+		return p
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		f := setup(t)
+		p := create(t, f.Storage, "lamp", f.Owners[0])
+		assert.NotZero(t, p.ID)
+		assert.Equal(t, "lamp", p.Title)
+		assert.Equal(t, "about lamp", p.Description)
+		assert.Equal(t, f.Owners[0], p.OwnerId)
+		assert.Equal(t, 1, p.Version)
+
+		revisions, err := f.Storage.FindRevisions(ctx, p.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, 1, revisions[0].Version)
+		assert.Equal(t, "lamp", revisions[0].Title)
+		assert.Equal(t, f.Owners[0], revisions[0].AuthorId)
+		assert.Nil(t, revisions[0].RollbackOf)
+		assert.False(t, revisions[0].CreatedAt.IsZero())
+	})
+
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		f := setup(t)
+		create(t, f.Storage, "lamp", f.Owners[0])
+		_, err := f.Storage.Create(ctx, product.CreateProductDTO{Title: "lamp", Description: "x", OwnerId: f.Owners[1]})
+		assert.ErrorIs(t, err, apperror.ProductTitleAlreadyExist)
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		f := setup(t)
+		p := create(t, f.Storage, "lamp", f.Owners[0])
+
+		found, err := f.Storage.FindOne(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, p.ID, found.ID)
+		assert.Equal(t, "lamp", found.Title)
+		assert.Equal(t, 1, found.Version)
+		assert.Empty(t, found.Reactions)
+		assert.Zero(t, found.Bookmarks)
+
+		_, err = f.Storage.FindOne(ctx, 4242)
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		f := setup(t)
+		all, err := f.Storage.FindAll(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, all)
+		assert.Empty(t, all)
+
+		lamp := create(t, f.Storage, "lamp", f.Owners[0])
+		desk := create(t, f.Storage, "desk", f.Owners[0])
+		chair := create(t, f.Storage, "chair", f.Owners[1])
+
+		all, err = f.Storage.FindAll(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{lamp.ID, desk.ID, chair.ID}, ids(all))
+
+		owned, err := f.Storage.FindUserAllProducts(ctx, f.Owners[0])
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{lamp.ID, desk.ID}, ids(owned))
+
+		none, err := f.Storage.FindUserAllProducts(ctx, 4242)
+		require.NoError(t, err)
+		assert.NotNil(t, none)
+		assert.Empty(t, none)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		f := setup(t)
+		p := create(t, f.Storage, "lamp", f.Owners[0])
+
+		updated, err := f.Storage.Update(ctx, p, product.UpdateProductDTO{Title: "desk lamp", Description: "bright"}, f.Owners[1])
+		require.NoError(t, err)
+		assert.Equal(t, "desk lamp", updated.Title)
+		assert.Equal(t, "bright", updated.Description)
+		assert.Equal(t, 2, updated.Version)
+
+		revision, err := f.Storage.FindRevision(ctx, p.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "desk lamp", revision.Title)
+		assert.Equal(t, f.Owners[1], revision.AuthorId)
+	})
+
+	t.Run("UpdatePreconditions", func(t *testing.T) {
+		f := setup(t)
+		p := create(t, f.Storage, "lamp", f.Owners[0])
+
+		stale := *p
+		_, err := f.Storage.Update(ctx, p, product.UpdateProductDTO{Title: "desk lamp", Description: "x"}, f.Owners[0])
+		require.NoError(t, err)
+		_, err = f.Storage.Update(ctx, &stale, product.UpdateProductDTO{Title: "floor lamp", Description: "x"}, f.Owners[0])
+		assert.ErrorIs(t, err, apperror.PreconditionFailed, "stale version")
+
+		found, err := f.Storage.FindOne(ctx, p.ID)
+		require.NoError(t, err)
+		notOwner := *found
+		notOwner.OwnerId = f.Owners[1]
+		_, err = f.Storage.Update(ctx, &notOwner, product.UpdateProductDTO{Title: "floor lamp", Description: "x"}, f.Owners[1])
+		assert.ErrorIs(t, err, apperror.PreconditionFailed, "other owner")
+	})
+
+	t.Run("UpdateDuplicate", func(t *testing.T) {
+		f := setup(t)
+		create(t, f.Storage, "lamp", f.Owners[0])
+		desk := create(t, f.Storage, "desk", f.Owners[0])
+		_, err := f.Storage.Update(ctx, desk, product.UpdateProductDTO{Title: "lamp", Description: "x"}, f.Owners[0])
+		assert.ErrorIs(t, err, apperror.ProductTitleAlreadyExist)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		f := setup(t)
+		p := create(t, f.Storage, "lamp", f.Owners[0])
+		p, err := f.Storage.Update(ctx, p, product.UpdateProductDTO{Title: "desk lamp", Description: "bright"}, f.Owners[0])
+		require.NoError(t, err)
+
+		first, err := f.Storage.FindRevision(ctx, p.ID, 1)
+		require.NoError(t, err)
+		rolledBack, err := f.Storage.Rollback(ctx, p, *first, f.Owners[1])
+		require.NoError(t, err)
+		assert.Equal(t, "lamp", rolledBack.Title)
+		assert.Equal(t, "about lamp", rolledBack.Description)
+		assert.Equal(t, 3, rolledBack.Version)
+
+		revisions, err := f.Storage.FindRevisions(ctx, p.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 3)
+		assert.Equal(t, []int{3, 2, 1}, []int{revisions[0].Version, revisions[1].Version, revisions[2].Version}, "newest first")
+		require.NotNil(t, revisions[0].RollbackOf)
+		assert.Equal(t, 1, *revisions[0].RollbackOf)
+		assert.Equal(t, f.Owners[1], revisions[0].AuthorId)
+	})
+
+	t.Run("FindRevisionNotFound", func(t *testing.T) {
+		f := setup(t)
+		p := create(t, f.Storage, "lamp", f.Owners[0])
+		_, err := f.Storage.FindRevision(ctx, p.ID, 2)
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		f := setup(t)
+		p := create(t, f.Storage, "lamp", f.Owners[0])
+
+		require.NoError(t, f.Storage.Delete(ctx, p.ID))
+		_, err := f.Storage.FindOne(ctx, p.ID)
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+		revisions, err := f.Storage.FindRevisions(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Empty(t, revisions, "revisions go with the product")
+		assert.ErrorIs(t, f.Storage.Delete(ctx, p.ID), apperror.ErrorNotFound)
+	})
+}
+
+func ids(products []product.Product) []int {
+	ids := make([]int, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
@@ -0,0 +1,70 @@
+package product
+
+import (
+	"context"
+	"go.mod/pkg/tracing"
+)
+
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps s so that every call is recorded as a child span of
+// the span carried by its context.
+func NewTracedService(s Service) Service {
+	return &tracedService{next: s}
+}
+
+func (t *tracedService) Create(ctx context.Context, post CreateProductDTO) (result *Product, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/Create")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Create(ctx, post)
+}
+
+func (t *tracedService) Delete(ctx context.Context, postId int) (err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/Delete")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Delete(ctx, postId)
+}
+
+func (t *tracedService) Update(ctx context.Context, post *Product, postUpdate UpdateProductDTO, authorId int) (u *Product, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/Update")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Update(ctx, post, postUpdate, authorId)
+}
+
+func (t *tracedService) FindAll(ctx context.Context) (result []Product, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/FindAll")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindAll(ctx)
+}
+
+func (t *tracedService) FindOneById(ctx context.Context, id int) (u *Product, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/FindOneById")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindOneById(ctx, id)
+}
+
+func (t *tracedService) FindUserPosts(ctx context.Context, userId int) (result []Product, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/FindUserPosts")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindUserPosts(ctx, userId)
+}
+
+func (t *tracedService) FindRevisions(ctx context.Context, id int) (result []Revision, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/FindRevisions")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindRevisions(ctx, id)
+}
+
+func (t *tracedService) Diff(ctx context.Context, id, from, to int) (result *RevisionDiff, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/Diff")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Diff(ctx, id, from, to)
+}
+
+func (t *tracedService) Rollback(ctx context.Context, post *Product, version, authorId int) (u *Product, err error) {
+	ctx, span := tracing.Start(ctx, "product.Service/Rollback")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Rollback(ctx, post, version, authorId)
+}
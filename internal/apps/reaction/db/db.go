@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"go.mod/internal/apps/reaction"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/logging"
+	"go.mod/pkg/utils"
+)
+
+type reactionRepository struct {
+	client    postgresql.Client
+	publisher cache.Publisher
+	logger    *logging.Logger
+}
+
+// NewReactionRepository publishes the cache key of a product whenever its
+// counters may have changed to publisher.
+func NewReactionRepository(client postgresql.Client, publisher cache.Publisher, logger *logging.Logger) reaction.Storage {
+	return &reactionRepository{
+		client:    client,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// exec runs a toggle statement. Every toggle inserts or deletes the membership
+// row and adjusts public.product_counter in the same statement, so the counter
+// only moves when the membership actually changed and repeated calls are no-ops.
+// Decrements stop at zero should a counter ever have drifted below the rows.
+// Cached copies of the product carry the counters and are invalidated.
+func (r *reactionRepository) exec(ctx context.Context, productId int, q string, args ...interface{}) error {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	if _, err := r.client.Exec(ctx, q, args...); err != nil {
+		return apperror.FromPostgres(err)
+	}
+	if err := r.publisher.Publish(ctx, product.CacheKey(productId)); err != nil {
+		logging.FromContext(ctx).Warnf("publish cache invalidation: %v", err)
+	}
+	return nil
+}
+
+func (r *reactionRepository) AddReaction(ctx context.Context, reactionObj reaction.Reaction) error {
+	ctx = postgresql.Named(ctx, "reaction", "AddReaction")
+	q := `
+	WITH inserted AS (
+	    INSERT INTO public.product_reaction (product_id, user_id, kind)
+	    VALUES ($1, $2, $3)
+	    ON CONFLICT DO NOTHING
+	    RETURNING product_id
+	)
+	INSERT INTO public.product_counter (product_id, kind, count)
+	SELECT product_id, $3, 1 FROM inserted
+	ON CONFLICT (product_id, kind) DO UPDATE SET count = public.product_counter.count + 1`
+	return r.exec(ctx, reactionObj.ProductId, q, reactionObj.ProductId, reactionObj.UserId, reactionObj.Kind)
+}
+
+func (r *reactionRepository) RemoveReaction(ctx context.Context, reactionObj reaction.Reaction) error {
+	ctx = postgresql.Named(ctx, "reaction", "RemoveReaction")
+	q := `
+	WITH deleted AS (
+	    DELETE FROM public.product_reaction
+	    WHERE product_id = $1 AND user_id = $2 AND kind = $3
+	    RETURNING product_id
+	)
+	UPDATE public.product_counter
+	SET count = GREATEST(count - 1, 0)
+	WHERE kind = $3 AND product_id IN (SELECT product_id FROM deleted)`
+	return r.exec(ctx, reactionObj.ProductId, q, reactionObj.ProductId, reactionObj.UserId, reactionObj.Kind)
+}
+
+func (r *reactionRepository) AddBookmark(ctx context.Context, bookmark reaction.Bookmark) error {
+	ctx = postgresql.Named(ctx, "reaction", "AddBookmark")
+	q := `
+	WITH inserted AS (
+	    INSERT INTO public.product_bookmark (product_id, user_id)
+	    VALUES ($1, $2)
+	    ON CONFLICT DO NOTHING
+	    RETURNING product_id
+	)
+	INSERT INTO public.product_counter (product_id, kind, count)
+	SELECT product_id, $3, 1 FROM inserted
+	ON CONFLICT (product_id, kind) DO UPDATE SET count = public.product_counter.count + 1`
+	return r.exec(ctx, bookmark.ProductId, q, bookmark.ProductId, bookmark.UserId, reaction.BookmarkCounter)
+}
+
+func (r *reactionRepository) RemoveBookmark(ctx context.Context, bookmark reaction.Bookmark) error {
+	ctx = postgresql.Named(ctx, "reaction", "RemoveBookmark")
+	q := `
+	WITH deleted AS (
+	    DELETE FROM public.product_bookmark
+	    WHERE product_id = $1 AND user_id = $2
+	    RETURNING product_id
+	)
+	UPDATE public.product_counter
+	SET count = GREATEST(count - 1, 0)
+	WHERE kind = $3 AND product_id IN (SELECT product_id FROM deleted)`
+	return r.exec(ctx, bookmark.ProductId, q, bookmark.ProductId, bookmark.UserId, reaction.BookmarkCounter)
+}
+
+func (r *reactionRepository) FindCounters(ctx context.Context, productId int) (c *reaction.Counters, err error) {
+	ctx = postgresql.Named(ctx, "reaction", "FindCounters")
+	q := `SELECT kind, count FROM public.product_counter WHERE product_id = $1`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	query, err := r.client.Query(ctx, q, productId)
+	if err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	defer query.Close()
+
+	counters := reaction.Counters{ProductId: productId, Reactions: make(map[string]int)}
+	for query.Next() {
+		var (
+			kind  string
+			count int
+		)
+		if err := query.Scan(&kind, &count); err != nil {
+			return nil, apperror.FromPostgres(err)
+		}
+		if kind == reaction.BookmarkCounter {
+			counters.Bookmarks = count
+			continue
+		}
+		counters.Reactions[kind] = count
+	}
+	if err = query.Err(); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return &counters, nil
+}
+
+func (r *reactionRepository) FindUserBookmarks(ctx context.Context, userId int) ([]product.Product, error) {
+	ctx = postgresql.Named(ctx, "reaction", "FindUserBookmarks")
+	q := `
+	SELECT p.id, p.title, p.description, p.owner_id
+	FROM public.product_bookmark b
+	JOIN public.product p ON p.id = b.product_id
+	WHERE b.user_id = $1
+	ORDER BY b.created_at DESC`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	query, err := r.client.Query(ctx, q, userId)
+	if err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	defer query.Close()
+
+	products := make([]product.Product, 0)
+	for query.Next() {
+		var productInfo product.Product
+		if err := query.Scan(&productInfo.ID, &productInfo.Title, &productInfo.Description, &productInfo.OwnerId); err != nil {
+			return nil, apperror.FromPostgres(err)
+		}
+		products = append(products, productInfo)
+	}
+	if err = query.Err(); err != nil {
+		return nil, apperror.FromPostgres(err)
+	}
+	return products, nil
+}
@@ -0,0 +1,69 @@
+package db_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apps/reaction"
+	"go.mod/internal/apps/reaction/db"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql/pgtest"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+func TestReactionCounters(t *testing.T) {
+	ctx := context.Background()
+	client := pgtest.Client(t)
+	alice, bob := pgtest.CreateUser(t, client, "alice"), pgtest.CreateUser(t, client, "bob")
+	var productId int
+	require.NoError(t, client.QueryRow(ctx,
+		`INSERT INTO public.product (title, description, owner_id) VALUES ('lamp', 'a lamp', $1) RETURNING id`,
+		alice).Scan(&productId))
+	storage := db.NewReactionRepository(client, cache.NopPublisher, logging.GetLogger())
+
+	counters := func() *reaction.Counters {
+		t.Helper()
+		c, err := storage.FindCounters(ctx, productId)
+		require.NoError(t, err)
+		return c
+	}
+
+	like := reaction.Reaction{ProductId: productId, UserId: alice, Kind: reaction.KindLike}
+	require.NoError(t, storage.AddReaction(ctx, like))
+	require.NoError(t, storage.AddReaction(ctx, like))
+	assert.Equal(t, 1, counters().Reactions[reaction.KindLike], "a repeated reaction is counted once")
+
+	require.NoError(t, storage.AddReaction(ctx, reaction.Reaction{ProductId: productId, UserId: bob, Kind: reaction.KindLike}))
+	require.NoError(t, storage.AddReaction(ctx, reaction.Reaction{ProductId: productId, UserId: bob, Kind: reaction.KindWow}))
+	assert.Equal(t, map[string]int{reaction.KindLike: 2, reaction.KindWow: 1}, counters().Reactions)
+
+	require.NoError(t, storage.RemoveReaction(ctx, like))
+	require.NoError(t, storage.RemoveReaction(ctx, like))
+	assert.Equal(t, 1, counters().Reactions[reaction.KindLike], "a repeated removal is a no-op")
+
+	require.NoError(t, storage.RemoveReaction(ctx, reaction.Reaction{ProductId: productId, UserId: alice, Kind: reaction.KindSad}))
+	_, ok := counters().Reactions[reaction.KindSad]
+	assert.False(t, ok, "removing a missing reaction creates no counter")
+
+	bookmark := reaction.Bookmark{ProductId: productId, UserId: alice}
+	require.NoError(t, storage.AddBookmark(ctx, bookmark))
+	require.NoError(t, storage.AddBookmark(ctx, bookmark))
+	assert.Equal(t, 1, counters().Bookmarks)
+	bookmarks, err := storage.FindUserBookmarks(ctx, alice)
+	require.NoError(t, err)
+	require.Len(t, bookmarks, 1)
+	assert.Equal(t, productId, bookmarks[0].ID)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, storage.RemoveBookmark(ctx, bookmark))
+	}
+	assert.Zero(t, counters().Bookmarks)
+
+	// a counter that drifted below its rows never goes negative
+	require.NoError(t, storage.AddBookmark(ctx, bookmark))
+	_, err = client.Exec(ctx, `UPDATE public.product_counter SET count = 0 WHERE product_id = $1 AND kind = $2`, productId, reaction.BookmarkCounter)
+	require.NoError(t, err)
+	require.NoError(t, storage.RemoveBookmark(ctx, bookmark))
+	assert.Zero(t, counters().Bookmarks)
+}
@@ -0,0 +1,38 @@
+package reaction
+
+const (
+	KindLike  = "like"
+	KindLove  = "love"
+	KindLaugh = "laugh"
+	KindWow   = "wow"
+	KindSad   = "sad"
+	KindAngry = "angry"
+)
+
+// BookmarkCounter is the counter table kind under which bookmarks are tallied.
+const BookmarkCounter = "bookmark"
+
+type Reaction struct {
+	ProductId int    `json:"product_id"`
+	UserId    int    `json:"user_id"`
+	Kind      string `json:"kind"`
+}
+
+type Bookmark struct {
+	ProductId int `json:"product_id"`
+	UserId    int `json:"user_id"`
+}
+
+type Counters struct {
+	ProductId int            `json:"product_id"`
+	Reactions map[string]int `json:"reactions"`
+	Bookmarks int            `json:"bookmarks"`
+}
+
+func IsValidKind(kind string) bool {
+	switch kind {
+	case KindLike, KindLove, KindLaugh, KindWow, KindSad, KindAngry:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package reaction
+
+import (
+	"context"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	"go.mod/pkg/logging"
+)
+
+type Service interface {
+	React(ctx context.Context, reaction Reaction) (c *Counters, err error)
+	Unreact(ctx context.Context, reaction Reaction) (c *Counters, err error)
+	Bookmark(ctx context.Context, bookmark Bookmark) (c *Counters, err error)
+	Unbookmark(ctx context.Context, bookmark Bookmark) (c *Counters, err error)
+	FindCounters(ctx context.Context, productId int) (c *Counters, err error)
+	FindUserBookmarks(ctx context.Context, userId int) ([]product.Product, error)
+}
+
+type reactionService struct {
+	storage Storage
+	logger  *logging.Logger
+}
+
+func NewService(storage Storage, logger *logging.Logger) Service {
+	return &reactionService{
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+func (s *reactionService) React(ctx context.Context, reaction Reaction) (c *Counters, err error) {
+	if !IsValidKind(reaction.Kind) {
+		return nil, apperror.InvalidReactionKind
+	}
+	if err := s.storage.AddReaction(ctx, reaction); err != nil {
+		return nil, err
+	}
+	return s.storage.FindCounters(ctx, reaction.ProductId)
+}
+
+func (s *reactionService) Unreact(ctx context.Context, reaction Reaction) (c *Counters, err error) {
+	if !IsValidKind(reaction.Kind) {
+		return nil, apperror.InvalidReactionKind
+	}
+	if err := s.storage.RemoveReaction(ctx, reaction); err != nil {
+		return nil, err
+	}
+	return s.storage.FindCounters(ctx, reaction.ProductId)
+}
+
+func (s *reactionService) Bookmark(ctx context.Context, bookmark Bookmark) (c *Counters, err error) {
+	if err := s.storage.AddBookmark(ctx, bookmark); err != nil {
+		return nil, err
+	}
+	return s.storage.FindCounters(ctx, bookmark.ProductId)
+}
+
+func (s *reactionService) Unbookmark(ctx context.Context, bookmark Bookmark) (c *Counters, err error) {
+	if err := s.storage.RemoveBookmark(ctx, bookmark); err != nil {
+		return nil, err
+	}
+	return s.storage.FindCounters(ctx, bookmark.ProductId)
+}
+
+func (s *reactionService) FindCounters(ctx context.Context, productId int) (c *Counters, err error) {
+	counters, err := s.storage.FindCounters(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	return counters, nil
+}
+
+func (s *reactionService) FindUserBookmarks(ctx context.Context, userId int) ([]product.Product, error) {
+	bookmarks, err := s.storage.FindUserBookmarks(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
@@ -0,0 +1,15 @@
+package reaction
+
+import (
+	"context"
+	"go.mod/internal/apps/product"
+)
+
+type Storage interface {
+	AddReaction(ctx context.Context, reaction Reaction) error
+	RemoveReaction(ctx context.Context, reaction Reaction) error
+	AddBookmark(ctx context.Context, bookmark Bookmark) error
+	RemoveBookmark(ctx context.Context, bookmark Bookmark) error
+	FindCounters(ctx context.Context, productId int) (c *Counters, err error)
+	FindUserBookmarks(ctx context.Context, userId int) ([]product.Product, error)
+}
@@ -0,0 +1,53 @@
+package reaction
+
+import (
+	"context"
+	"go.mod/internal/apps/product"
+	"go.mod/pkg/tracing"
+)
+
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps s so that every call is recorded as a child span of
+// the span carried by its context.
+func NewTracedService(s Service) Service {
+	return &tracedService{next: s}
+}
+
+func (t *tracedService) React(ctx context.Context, reaction Reaction) (c *Counters, err error) {
+	ctx, span := tracing.Start(ctx, "reaction.Service/React")
+	defer func() { tracing.End(span, err) }()
+	return t.next.React(ctx, reaction)
+}
+
+func (t *tracedService) Unreact(ctx context.Context, reaction Reaction) (c *Counters, err error) {
+	ctx, span := tracing.Start(ctx, "reaction.Service/Unreact")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Unreact(ctx, reaction)
+}
+
+func (t *tracedService) Bookmark(ctx context.Context, bookmark Bookmark) (c *Counters, err error) {
+	ctx, span := tracing.Start(ctx, "reaction.Service/Bookmark")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Bookmark(ctx, bookmark)
+}
+
+func (t *tracedService) Unbookmark(ctx context.Context, bookmark Bookmark) (c *Counters, err error) {
+	ctx, span := tracing.Start(ctx, "reaction.Service/Unbookmark")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Unbookmark(ctx, bookmark)
+}
+
+func (t *tracedService) FindCounters(ctx context.Context, productId int) (c *Counters, err error) {
+	ctx, span := tracing.Start(ctx, "reaction.Service/FindCounters")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindCounters(ctx, productId)
+}
+
+func (t *tracedService) FindUserBookmarks(ctx context.Context, userId int) (result []product.Product, err error) {
+	ctx, span := tracing.Start(ctx, "reaction.Service/FindUserBookmarks")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindUserBookmarks(ctx, userId)
+}
@@ -0,0 +1,65 @@
+// Package signup registers a user together with their first product. Both
+// are created in one transaction, a failing product leaves no user behind.
+package signup
+
+import (
+	"context"
+	"go.mod/internal/apps/product"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/logging"
+)
+
+type CreateSignupDTO struct {
+	User    user.CreateUserDTO       `json:"user"`
+	Product product.CreateProductDTO `json:"product"`
+}
+
+type Signup struct {
+	User    *user.User       `json:"user"`
+	Product *product.Product `json:"product"`
+}
+
+type Service interface {
+	Create(ctx context.Context, signupDTO CreateSignupDTO) (*Signup, error)
+}
+
+type signupService struct {
+	users    user.Service
+	products product.Service
+	tx       postgresql.Transactor
+	logger   *logging.Logger
+}
+
+func NewService(users user.Service, products product.Service, tx postgresql.Transactor, logger *logging.Logger) Service {
+	return &signupService{
+		users:    users,
+		products: products,
+		tx:       tx,
+		logger:   logger,
+	}
+}
+
+// Create makes the new user the owner of the product, an owner given in the
+// request is ignored.
+func (s *signupService) Create(ctx context.Context, signupDTO CreateSignupDTO) (*Signup, error) {
+	var signup Signup
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		u, err := s.users.Create(ctx, signupDTO.User)
+		if err != nil {
+			return err
+		}
+		productDTO := signupDTO.Product
+		productDTO.OwnerId = u.ID
+		p, err := s.products.Create(ctx, productDTO)
+		if err != nil {
+			return err
+		}
+		signup = Signup{User: u, Product: p}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &signup, nil
+}
@@ -0,0 +1,64 @@
+package signup_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/product"
+	productdb "go.mod/internal/apps/product/db"
+	productmemory "go.mod/internal/apps/product/memory"
+	"go.mod/internal/apps/signup"
+	"go.mod/internal/apps/user"
+	userdb "go.mod/internal/apps/user/db"
+	usermemory "go.mod/internal/apps/user/memory"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql/pgtest"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+// noTx runs the function without a transaction.
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func signupDTO(username, title string) signup.CreateSignupDTO {
+	return signup.CreateSignupDTO{
+		User: user.CreateUserDTO{Username: username, Email: username + "@example.com", Password: "secret", RepeatPassword: "secret"},
+		// the owner is replaced with the new user
+		Product: product.CreateProductDTO{Title: title, Description: "about " + title, OwnerId: 99},
+	}
+}
+
+func TestCreateOwnsProduct(t *testing.T) {
+	logger := logging.GetLogger()
+	products := product.NewService(productmemory.NewProductRepository(), noTx{}, logger)
+	s := signup.NewService(user.NewUserService(usermemory.NewUserRepository(), logger), products, noTx{}, logger)
+
+	created, err := s.Create(context.Background(), signupDTO("alice", "lamp"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", created.User.Username)
+	assert.Equal(t, created.User.ID, created.Product.OwnerId)
+
+	_, err = s.Create(context.Background(), signupDTO("alice", "desk"))
+	assert.ErrorIs(t, err, apperror.UserAlreadyExist)
+}
+
+func TestCreateIsAtomic(t *testing.T) {
+	client := pgtest.Client(t)
+	logger := logging.GetLogger()
+	users := userdb.NewUserRepository(client, logger)
+	products := product.NewService(productdb.NewProductRepository(client, cache.NopPublisher, logger), client, logger)
+	s := signup.NewService(user.NewUserService(users, logger), products, client, logger)
+
+	_, err := s.Create(context.Background(), signupDTO("alice", "lamp"))
+	require.NoError(t, err)
+
+	_, err = s.Create(context.Background(), signupDTO("bob", "lamp"))
+	assert.ErrorIs(t, err, apperror.ProductTitleAlreadyExist)
+	_, err = users.FindOneByUsername(context.Background(), "bob")
+	assert.ErrorIs(t, err, apperror.ErrorNotFound, "the user of a failed signup is rolled back")
+}
@@ -0,0 +1,22 @@
+package signup
+
+import (
+	"context"
+	"go.mod/pkg/tracing"
+)
+
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps s so that every call is recorded as a child span of
+// the span carried by its context.
+func NewTracedService(s Service) Service {
+	return &tracedService{next: s}
+}
+
+func (t *tracedService) Create(ctx context.Context, signupDTO CreateSignupDTO) (result *Signup, err error) {
+	ctx, span := tracing.Start(ctx, "signup.Service/Create")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Create(ctx, signupDTO)
+}
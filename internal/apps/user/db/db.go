@@ -2,8 +2,9 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"go.mod/internal/apperror"
 	"go.mod/internal/apps/user"
 	"go.mod/pkg/client/postgresql"
@@ -24,86 +25,85 @@ func NewUserRepository(client postgresql.Client, logger *logging.Logger) user.St
 }
 
 func (r *userRepository) Create(ctx context.Context, userDTO user.User) (u *user.User, err error) {
-	q := `INSERT INTO public.user (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, username, email`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
-	if err := r.client.QueryRow(ctx, q, userDTO.Username, userDTO.Email, userDTO.Password).Scan(&userDTO.ID, &userDTO.Username, &userDTO.Email); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			if pgErr.Code == "23505" {
-				return nil, apperror.UserAlreadyExist
-			}
-			return nil, newErr
-		}
-		return nil, err
+	ctx = postgresql.Named(ctx, "user", "Create")
+	if userDTO.Role == "" {
+		userDTO.Role = user.RoleUser
+	}
+	q := `INSERT INTO public.user (username, email, password_hash, role) VALUES ($1, $2, $3, $4) RETURNING id, username, email, role, version`
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	if err := r.client.QueryRow(ctx, q, userDTO.Username, userDTO.Email, userDTO.Password, userDTO.Role).Scan(&userDTO.ID, &userDTO.Username, &userDTO.Email, &userDTO.Role, &userDTO.Version); err != nil {
+		return nil, apperror.FromPostgres(err, apperror.UserAlreadyExist)
 	}
 
 	return &userDTO, nil
 }
 
 func (r *userRepository) Update(ctx context.Context, userObj user.User, userUpdate user.UpdateUserDTO) (u *user.User, err error) {
+	ctx = postgresql.Named(ctx, "user", "Update")
 	q := `
 	UPDATE public.user 
-	SET username = $1, email = $2, password_hash = $3 
+	SET username = $1, email = $2, password_hash = $3, version = version + 1
 	WHERE id = (
 	    SELECT id
 	    FROM public.user
 	    WHERE id = $4
+	    AND version = $5
 	    LIMIT 1
 	    FOR UPDATE 
 	)
-	RETURNING id, username, email, password_hash;`
-
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
-	if err := r.client.QueryRow(ctx, q, userUpdate.Username, userUpdate.Email, userUpdate.PasswordHash, userObj.ID).Scan(&userObj.ID, &userObj.Username, &userObj.Email, &userObj.Password); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			if pgErr.Code == "23505" {
-				return nil, apperror.UserAlreadyExist
-			}
-			return nil, newErr
+	RETURNING id, username, email, password_hash, role, version;`
+
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	if err := r.client.QueryRow(ctx, q, userUpdate.Username, userUpdate.Email, userUpdate.PasswordHash, userObj.ID, userObj.Version).Scan(&userObj.ID, &userObj.Username, &userObj.Email, &userObj.Password, &userObj.Role, &userObj.Version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.PreconditionFailed
 		}
+		return nil, apperror.FromPostgres(err, apperror.UserAlreadyExist)
 	}
 	return &userObj, nil
 }
 
 func (r *userRepository) Delete(ctx context.Context, id int) error {
+	ctx = postgresql.Named(ctx, "user", "Delete")
 	q := `
 	DELETE FROM public.user WHERE id=$1
 	`
 
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
-	if err := r.client.QueryRow(ctx, q, id).Scan(&id); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			return newErr
-		}
-		return err
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	tag, err := r.client.Exec(ctx, q, id)
+	if err != nil {
+		return apperror.FromPostgres(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrorNotFound
 	}
 	return nil
 
 }
 
 func (r *userRepository) FindAll(ctx context.Context) (u []user.User, err error) {
-	q := `SELECT id, username, email FROM public.user`
+	ctx = postgresql.Named(ctx, "user", "FindAll")
+	q := `SELECT id, username, email, role, version FROM public.user`
 	query, err := r.client.Query(ctx, q)
 	if err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
+	defer query.Close()
 
 	users := make([]user.User, 0)
 
 	for query.Next() {
 		var userInfo user.User
-		err := query.Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email)
+		err := query.Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email, &userInfo.Role, &userInfo.Version)
 		if err != nil {
-			return nil, err
+			return nil, apperror.FromPostgres(err)
 		}
 
 		users = append(users, userInfo)
 	}
 
 	if err = query.Err(); err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
 
 	return users, nil
@@ -111,35 +111,33 @@ func (r *userRepository) FindAll(ctx context.Context) (u []user.User, err error)
 }
 
 func (r *userRepository) FindOneById(ctx context.Context, id int) (u *user.User, err error) {
+	ctx = postgresql.Named(ctx, "user", "FindOneById")
 	q := `
-		SELECT id, username, email FROM public.user WHERE id = $1
+		SELECT id, username, email, role, version FROM public.user WHERE id = $1
 	`
 
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 
 	var userInfo user.User
-	if err := r.client.QueryRow(ctx, q, id).Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf(fmt.Sprintf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s", pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState()))
-			return nil, newErr
-		}
-		return nil, err
+	if err := r.client.QueryRow(ctx, q, id).Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email, &userInfo.Role, &userInfo.Version); err != nil {
+		return nil, apperror.FromPostgres(err)
 	}
 	return &userInfo, nil
 
 }
 
 func (r *userRepository) FindOneByUsername(ctx context.Context, username string) (u *user.User, err error) {
+	ctx = postgresql.Named(ctx, "user", "FindOneByUsername")
 	q := `
-		SELECT id, username, email, password_hash FROM public.user WHERE username = $1
+		SELECT id, username, email, password_hash, role, version FROM public.user WHERE username = $1
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 
 	var userInfo user.User
 
-	err = r.client.QueryRow(ctx, q, username).Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email, &userInfo.Password)
+	err = r.client.QueryRow(ctx, q, username).Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email, &userInfo.Password, &userInfo.Role, &userInfo.Version)
 	if err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
 
 	return &userInfo, nil
@@ -147,16 +145,17 @@ func (r *userRepository) FindOneByUsername(ctx context.Context, username string)
 }
 
 func (r *userRepository) FindOneByEmail(ctx context.Context, email string) (u *user.User, err error) {
+	ctx = postgresql.Named(ctx, "user", "FindOneByEmail")
 	q := `
-		SELECT id, username, email FROM public.user WHERE email = $1
+		SELECT id, username, email, role, version FROM public.user WHERE email = $1
 	`
-	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
 
 	var userInfo user.User
 
-	err = r.client.QueryRow(ctx, q, email).Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email)
+	err = r.client.QueryRow(ctx, q, email).Scan(&userInfo.ID, &userInfo.Username, &userInfo.Email, &userInfo.Role, &userInfo.Version)
 	if err != nil {
-		return nil, err
+		return nil, apperror.FromPostgres(err)
 	}
 
 	return &userInfo, nil
@@ -0,0 +1,16 @@
+package db_test
+
+import (
+	"go.mod/internal/apps/user"
+	"go.mod/internal/apps/user/db"
+	"go.mod/internal/apps/user/storagetest"
+	"go.mod/pkg/client/postgresql/pgtest"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) user.Storage {
+		return db.NewUserRepository(pgtest.Client(t), logging.GetLogger())
+	})
+}
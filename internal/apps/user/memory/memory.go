@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"sort"
+	"sync"
+)
+
+// userRepository keeps users in a map. It enforces the unique username and
+// email of the Postgres schema and reports the same errors, so services and
+// handlers can be tested without a database.
+type userRepository struct {
+	mu     sync.RWMutex
+	users  map[int]user.User
+	nextID int
+}
+
+func NewUserRepository() user.Storage {
+	return &userRepository{users: make(map[int]user.User)}
+}
+
+// taken reports whether another user than id has the username or email.
+func (r *userRepository) taken(id int, username, email string) bool {
+	for _, u := range r.users {
+		if u.ID != id && (u.Username == username || u.Email == email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *userRepository) Create(ctx context.Context, userDTO user.User) (u *user.User, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.taken(0, userDTO.Username, userDTO.Email) {
+		return nil, apperror.UserAlreadyExist
+	}
+	r.nextID++
+	userDTO.ID = r.nextID
+	userDTO.Version = 1
+	r.users[userDTO.ID] = userDTO
+	return &userDTO, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, userObj user.User, userUpdate user.UpdateUserDTO) (u *user.User, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[userObj.ID]
+	if !ok || stored.Version != userObj.Version {
+		return nil, apperror.PreconditionFailed
+	}
+	if r.taken(stored.ID, userUpdate.Username, userUpdate.Email) {
+		return nil, apperror.UserAlreadyExist
+	}
+	stored.Username = userUpdate.Username
+	stored.Email = userUpdate.Email
+	stored.Password = userUpdate.PasswordHash
+	stored.Version++
+	r.users[stored.ID] = stored
+	return &stored, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return apperror.ErrorNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *userRepository) FindAll(ctx context.Context) (u []user.User, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := make([]user.User, 0, len(r.users))
+	for _, stored := range r.users {
+		stored.Password = ""
+		users = append(users, stored)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *userRepository) FindOneById(ctx context.Context, id int) (u *user.User, err error) {
+	return r.findOne(func(u user.User) bool { return u.ID == id }, false)
+}
+
+// FindOneByUsername is the only lookup returning the password hash, it is
+// the one login uses.
+func (r *userRepository) FindOneByUsername(ctx context.Context, username string) (u *user.User, err error) {
+	return r.findOne(func(u user.User) bool { return u.Username == username }, true)
+}
+
+func (r *userRepository) FindOneByEmail(ctx context.Context, email string) (u *user.User, err error) {
+	return r.findOne(func(u user.User) bool { return u.Email == email }, false)
+}
+
+func (r *userRepository) findOne(match func(user.User) bool, withPassword bool) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, stored := range r.users {
+		if match(stored) {
+			if !withPassword {
+				stored.Password = ""
+			}
+			return &stored, nil
+		}
+	}
+	return nil, apperror.ErrorNotFound
+}
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"go.mod/internal/apps/user"
+	"go.mod/internal/apps/user/memory"
+	"go.mod/internal/apps/user/storagetest"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) user.Storage {
+		return memory.NewUserRepository()
+	})
+}
@@ -5,6 +5,13 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Roles are only assigned in the database, no endpoint changes them.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type CreateUserDTO struct {
 	Email          string `json:"email"`
 	Username       string `json:"username"`
@@ -36,6 +43,8 @@ type User struct {
 	Username string `json:"username" bson:"username"`
 	Password string `json:"-" bson:"password"`
 	Email    string `json:"email" bson:"email"`
+	Role     string `json:"role" bson:"role"`
+	Version  int    `json:"version" bson:"version"`
 }
 
 func (u *User) GeneratePasswordHash() error {
@@ -60,5 +69,6 @@ func NewUser(dto CreateUserDTO) User {
 		Username: dto.Username,
 		Password: dto.Password,
 		Email:    dto.Email,
+		Role:     RoleUser,
 	}
 }
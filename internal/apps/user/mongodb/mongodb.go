@@ -0,0 +1,126 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"go.mod/pkg/client/mongodb"
+	"go.mod/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collection = "users"
+
+type userRepository struct {
+	db     *mongo.Database
+	users  *mongo.Collection
+	logger *logging.Logger
+}
+
+// NewUserRepository returns a user.Storage keeping users in the users
+// collection. It creates the unique indexes on username and email the
+// Postgres schema has, so both backends reject the same duplicates.
+func NewUserRepository(ctx context.Context, db *mongo.Database, logger *logging.Logger) (user.Storage, error) {
+	users := db.Collection(collection)
+	_, err := users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s indexes: %w", collection, err)
+	}
+	return &userRepository{
+		db:     db,
+		users:  users,
+		logger: logger,
+	}, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, userDTO user.User) (u *user.User, err error) {
+	id, err := mongodb.NextID(ctx, r.db, collection)
+	if err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	userDTO.ID = id
+	userDTO.Version = 1
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.insertOne %d", collection, id))
+	if _, err := r.users.InsertOne(ctx, userDTO); err != nil {
+		return nil, apperror.FromMongo(err, apperror.UserAlreadyExist)
+	}
+	return &userDTO, nil
+}
+
+// Update applies only while the stored user is still at userObj.Version.
+func (r *userRepository) Update(ctx context.Context, userObj user.User, userUpdate user.UpdateUserDTO) (u *user.User, err error) {
+	filter := bson.M{"_id": userObj.ID, "version": userObj.Version}
+	update := bson.M{
+		"$set": bson.M{"username": userUpdate.Username, "email": userUpdate.Email, "password": userUpdate.PasswordHash},
+		"$inc": bson.M{"version": 1},
+	}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOneAndUpdate %v", collection, filter))
+	var updated user.User
+	err = r.users.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apperror.PreconditionFailed
+		}
+		return nil, apperror.FromMongo(err, apperror.UserAlreadyExist)
+	}
+	return &updated, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id int) error {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.deleteOne %d", collection, id))
+	result, err := r.users.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return apperror.FromMongo(err)
+	}
+	if result.DeletedCount == 0 {
+		return apperror.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) FindAll(ctx context.Context) (u []user.User, err error) {
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.find", collection))
+	cursor, err := r.users.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}).SetProjection(bson.M{"password": 0}))
+	if err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	users := make([]user.User, 0)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	return users, nil
+}
+
+func (r *userRepository) FindOneById(ctx context.Context, id int) (u *user.User, err error) {
+	return r.findOne(ctx, bson.M{"_id": id}, false)
+}
+
+// FindOneByUsername is the only lookup returning the password hash, it is
+// the one login uses.
+func (r *userRepository) FindOneByUsername(ctx context.Context, username string) (u *user.User, err error) {
+	return r.findOne(ctx, bson.M{"username": username}, true)
+}
+
+func (r *userRepository) FindOneByEmail(ctx context.Context, email string) (u *user.User, err error) {
+	return r.findOne(ctx, bson.M{"email": email}, false)
+}
+
+func (r *userRepository) findOne(ctx context.Context, filter bson.M, withPassword bool) (*user.User, error) {
+	findOptions := options.FindOne()
+	if !withPassword {
+		findOptions.SetProjection(bson.M{"password": 0})
+	}
+	logging.FromContext(ctx).Trace(fmt.Sprintf("Mongo Query: %s.findOne %v", collection, filter))
+	var userInfo user.User
+	if err := r.users.FindOne(ctx, filter, findOptions).Decode(&userInfo); err != nil {
+		return nil, apperror.FromMongo(err)
+	}
+	return &userInfo, nil
+}
@@ -0,0 +1,21 @@
+package mongodb_test
+
+import (
+	"context"
+	"go.mod/internal/apps/user"
+	"go.mod/internal/apps/user/mongodb"
+	"go.mod/internal/apps/user/storagetest"
+	"go.mod/pkg/client/mongodb/mongotest"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) user.Storage {
+		storage, err := mongodb.NewUserRepository(context.Background(), mongotest.Database(t), logging.GetLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return storage
+	})
+}
@@ -59,7 +59,7 @@ func (s userService) Create(ctx context.Context, createUser CreateUserDTO) (u *U
 	user := NewUser(createUser)
 	err = user.GeneratePasswordHash()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user due to error %v", err)
+		return nil, fmt.Errorf("failed to create user due to error %w", err)
 	}
 	u, err = s.storage.Create(ctx, user)
 	if err != nil {
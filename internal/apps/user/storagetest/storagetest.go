@@ -0,0 +1,136 @@
+// Package storagetest is the contract every user.Storage backend must pass.
+package storagetest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/internal/apps/user"
+	"testing"
+)
+
+// Run checks the storage returned by newStorage, which must be empty and is
+// called once per subtest.
+func Run(t *testing.T, newStorage func(t *testing.T) user.Storage) {
+	ctx := context.Background()
+	create := func(t *testing.T, s user.Storage, username string) *user.User {
+		t.Helper()
+		u, err := s.Create(ctx, user.User{Username: username, Email: username + "@example.com", Password: "hash-" + username})
+		require.NoError(t, err)
+		return u
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		s := newStorage(t)
+		u := create(t, s, "alice")
+		assert.NotZero(t, u.ID)
+		assert.Equal(t, "alice", u.Username)
+		assert.Equal(t, "alice@example.com", u.Email)
+		assert.Equal(t, 1, u.Version)
+
+		other := create(t, s, "bob")
+		assert.NotEqual(t, u.ID, other.ID)
+	})
+
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		s := newStorage(t)
+		create(t, s, "alice")
+
+		_, err := s.Create(ctx, user.User{Username: "alice", Email: "other@example.com", Password: "x"})
+		assert.ErrorIs(t, err, apperror.UserAlreadyExist, "same username")
+		_, err = s.Create(ctx, user.User{Username: "other", Email: "alice@example.com", Password: "x"})
+		assert.ErrorIs(t, err, apperror.UserAlreadyExist, "same email")
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		s := newStorage(t)
+		u := create(t, s, "alice")
+
+		byId, err := s.FindOneById(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", byId.Username)
+		assert.Empty(t, byId.Password, "only the username lookup returns the password")
+
+		byEmail, err := s.FindOneByEmail(ctx, "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, u.ID, byEmail.ID)
+
+		byUsername, err := s.FindOneByUsername(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, u.ID, byUsername.ID)
+		assert.Equal(t, "hash-alice", byUsername.Password)
+	})
+
+	t.Run("FindNotFound", func(t *testing.T) {
+		s := newStorage(t)
+		_, err := s.FindOneById(ctx, 4242)
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+		_, err = s.FindOneByEmail(ctx, "nobody@example.com")
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+		_, err = s.FindOneByUsername(ctx, "nobody")
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		s := newStorage(t)
+		all, err := s.FindAll(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, all)
+		assert.Empty(t, all)
+
+		alice, bob := create(t, s, "alice"), create(t, s, "bob")
+		all, err = s.FindAll(ctx)
+		require.NoError(t, err)
+		var ids []int
+		for _, u := range all {
+			ids = append(ids, u.ID)
+		}
+		assert.ElementsMatch(t, []int{alice.ID, bob.ID}, ids)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s := newStorage(t)
+		u := create(t, s, "alice")
+
+		updated, err := s.Update(ctx, *u, user.UpdateUserDTO{Username: "alicia", Email: "alicia@example.com", PasswordHash: "new-hash"})
+		require.NoError(t, err)
+		assert.Equal(t, u.ID, updated.ID)
+		assert.Equal(t, "alicia", updated.Username)
+		assert.Equal(t, "alicia@example.com", updated.Email)
+		assert.Equal(t, 2, updated.Version)
+
+		found, err := s.FindOneByUsername(ctx, "alicia")
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", found.Password)
+	})
+
+	t.Run("UpdateStaleVersion", func(t *testing.T) {
+		s := newStorage(t)
+		u := create(t, s, "alice")
+		_, err := s.Update(ctx, *u, user.UpdateUserDTO{Username: "alicia", Email: "alice@example.com", PasswordHash: "x"})
+		require.NoError(t, err)
+
+		_, err = s.Update(ctx, *u, user.UpdateUserDTO{Username: "ally", Email: "alice@example.com", PasswordHash: "x"})
+		assert.ErrorIs(t, err, apperror.PreconditionFailed)
+	})
+
+	t.Run("UpdateDuplicate", func(t *testing.T) {
+		s := newStorage(t)
+		create(t, s, "alice")
+		bob := create(t, s, "bob")
+
+		_, err := s.Update(ctx, *bob, user.UpdateUserDTO{Username: "alice", Email: "bob@example.com", PasswordHash: "x"})
+		assert.ErrorIs(t, err, apperror.UserAlreadyExist)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStorage(t)
+		u := create(t, s, "alice")
+
+		require.NoError(t, s.Delete(ctx, u.ID))
+		_, err := s.FindOneById(ctx, u.ID)
+		assert.ErrorIs(t, err, apperror.ErrorNotFound)
+		assert.ErrorIs(t, s.Delete(ctx, u.ID), apperror.ErrorNotFound)
+	})
+}
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"go.mod/pkg/tracing"
+)
+
+type tracedService struct {
+	next Service
+}
+
+// NewTracedService wraps s so that every call is recorded as a child span of
+// the span carried by its context.
+func NewTracedService(s Service) Service {
+	return &tracedService{next: s}
+}
+
+func (t *tracedService) Create(ctx context.Context, createUser CreateUserDTO) (u *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/Create")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Create(ctx, createUser)
+}
+
+func (t *tracedService) Delete(ctx context.Context, userId int) (err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/Delete")
+	defer func() { tracing.End(span, err) }()
+	return t.next.Delete(ctx, userId)
+}
+
+func (t *tracedService) UserUpdate(ctx context.Context, userObj User, updateUser UpdateUserDTO) (u *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/UserUpdate")
+	defer func() { tracing.End(span, err) }()
+	return t.next.UserUpdate(ctx, userObj, updateUser)
+}
+
+func (t *tracedService) FindAll(ctx context.Context) (result []User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/FindAll")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindAll(ctx)
+}
+
+func (t *tracedService) FindUserByUsernameAndPassword(ctx context.Context, username, password string) (u User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/FindUserByUsernameAndPassword")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindUserByUsernameAndPassword(ctx, username, password)
+}
+
+func (t *tracedService) FindOneById(ctx context.Context, id int) (u *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/FindOneById")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindOneById(ctx, id)
+}
+
+func (t *tracedService) FindOneByUsername(ctx context.Context, username string) (u *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/FindOneByUsername")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindOneByUsername(ctx, username)
+}
+
+func (t *tracedService) FindOneByEmail(ctx context.Context, email string) (u *User, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service/FindOneByEmail")
+	defer func() { tracing.End(span, err) }()
+	return t.next.FindOneByEmail(ctx, email)
+}
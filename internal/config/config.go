@@ -1,42 +1,215 @@
 package config
 
 import (
-	"github.com/ilyakaznacheev/cleanenv"
 	"go.mod/pkg/logging"
+	"os"
 	"sync"
+	"time"
 )
 
+// Config is the application configuration. String settings may reference a
+// secret as file://path, env://NAME or scheme://ref of a registered
+// SecretProvider, settings tagged secret are redacted when formatted.
 type Config struct {
 	Storage struct {
-		Host     string `json:"host"`
-		Port     string `json:"port"`
-		Database string `json:"database"`
-		Username string `json:"username"`
-		Password string `json:"password"`
+		// Backend keeps users, products and categories in postgres or mongodb,
+		// everything else is always stored in Postgres. Signups, reactions,
+		// comments and attachments reference users and products there, their
+		// apis are only served with the postgres backend.
+		Backend  string `yaml:"backend" env-default:"postgres"`
+		Host     string `yaml:"host"`
+		Port     string `yaml:"port" env-default:"5432"`
+		Database string `yaml:"database"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password" secret:"true"`
+		// SSLMode is one of disable, allow, prefer, require, verify-ca and
+		// verify-full, the certificate settings are paths to PEM files.
+		SSLMode         string        `yaml:"sslmode" env-default:"prefer"`
+		SSLRootCert     string        `yaml:"sslrootcert"`
+		SSLCert         string        `yaml:"sslcert"`
+		SSLKey          string        `yaml:"sslkey"`
+		ApplicationName string        `yaml:"application_name" env-default:"go-blog"`
+		ConnectTimeout  time.Duration `yaml:"connect_timeout" env-default:"5s"`
+		// StatementTimeout is set on every session, 0 lets queries run unbounded.
+		StatementTimeout time.Duration `yaml:"statement_timeout" env-default:"30s"`
+		Pool             struct {
+			MaxConns          int32         `yaml:"max_conns" env-default:"10"`
+			MinConns          int32         `yaml:"min_conns" env-default:"0"`
+			MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env-default:"1h"`
+			MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env-default:"30m"`
+			HealthCheckPeriod time.Duration `yaml:"health_check_period" env-default:"1m"`
+		} `yaml:"pool"`
+		// Replicas are host:port addresses of read replicas reached with the
+		// credentials and options above, read-only queries are spread over them.
+		Replicas             []string      `yaml:"replicas"`
+		ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env-default:"5s"`
+		// ReplicaMaxLag takes a replica out of rotation while it lags more, 0 disables.
+		ReplicaMaxLag time.Duration `yaml:"replica_max_lag" env-default:"10s"`
+		Retry         struct {
+			Attempts       int           `yaml:"attempts" env-default:"5"`
+			InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"500ms"`
+			MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"10s"`
+		} `yaml:"retry"`
 	} `yaml:"storage"`
+	MongoDB struct {
+		Host     string `yaml:"host" env-default:"localhost"`
+		Port     string `yaml:"port" env-default:"27017"`
+		Database string `yaml:"database"`
+		AuthDB   string `yaml:"auth_db"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password" secret:"true"`
+	} `yaml:"mongodb"`
 	JWT struct {
-		Secret string `yaml:"secret" env-required:"true"`
-	}
-	isDebug *bool `yaml:"is_debug env-required:true"`
+		Secret string `yaml:"secret" secret:"true"`
+	} `yaml:"jwt"`
+	// Cache holds product and category lookups in memory. Backend is one of
+	// freecache, lru and tinylfu, sizes are in bytes. MaxEntries bounds the
+	// lru and tinylfu backends by count as well, their Size may then be 0.
+	// Refresh tokens use lru when the backend is tinylfu.
+	Cache struct {
+		Backend    string        `yaml:"backend" env-default:"freecache"`
+		Size       int           `yaml:"size" env-default:"52428800"`
+		MaxEntries int           `yaml:"max_entries" env-default:"0"`
+		TTL        time.Duration `yaml:"ttl" env-default:"1m"`
+		// NegativeTTL is how long a not found lookup is remembered, 0 disables it.
+		NegativeTTL   time.Duration `yaml:"negative_ttl" env-default:"10s"`
+		RefreshTokens struct {
+			Size       int `yaml:"size" env-default:"104857600"`
+			MaxEntries int `yaml:"max_entries" env-default:"0"`
+		} `yaml:"refresh_tokens"`
+	} `yaml:"cache"`
+	IsDebug bool `yaml:"is_debug" env-default:"false"`
 	Listen  struct {
 		Type   string `yaml:"type" env-default:"port"`
 		BindIp string `yaml:"bind_ip" env-default:"0.0.0.0"`
 		Port   string `yaml:"port" env-default:"8000"`
-	} `yaml:"lister"`
+		// DrainDelay is how long the readiness probe fails before the listener
+		// closes on shutdown, so load balancers stop sending new requests.
+		DrainDelay time.Duration `yaml:"drain_delay" env-default:"5s"`
+		// ShutdownTimeout bounds how long in-flight requests may drain on shutdown.
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"20s"`
+	} `yaml:"listen"`
+	CORS struct {
+		AllowedOrigins []string `yaml:"allowed_origins" env-default:"*"`
+	} `yaml:"cors"`
+	HTTP struct {
+		RequestTimeout time.Duration            `yaml:"request_timeout" env-default:"10s"`
+		RouteTimeouts  map[string]time.Duration `yaml:"route_timeouts"`
+	} `yaml:"http"`
+	Logging struct {
+		Format   string            `yaml:"format" env-default:"text"`
+		Level    string            `yaml:"level" env-default:"info"`
+		Packages map[string]string `yaml:"packages"`
+		Outputs  []string          `yaml:"outputs" env-default:"stdout"`
+		File     struct {
+			Path       string `yaml:"path" env-default:"logs/all.log"`
+			MaxSizeMB  int    `yaml:"max_size_mb" env-default:"100"`
+			MaxBackups int    `yaml:"max_backups" env-default:"5"`
+			MaxAgeDays int    `yaml:"max_age_days" env-default:"30"`
+			Compress   bool   `yaml:"compress" env-default:"true"`
+		} `yaml:"file"`
+		Sampling struct {
+			Initial    int `yaml:"initial" env-default:"100"`
+			Thereafter int `yaml:"thereafter" env-default:"100"`
+		} `yaml:"sampling"`
+	} `yaml:"logging"`
+	Tracing struct {
+		Exporter    string  `yaml:"exporter" env-default:"none"`
+		Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"`
+		Insecure    bool    `yaml:"insecure" env-default:"true"`
+		File        string  `yaml:"file" env-default:"logs/traces.json"`
+		ServiceName string  `yaml:"service_name" env-default:"go-blog"`
+		SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	} `yaml:"tracing"`
+	Migrations struct {
+		Auto bool `yaml:"auto" env-default:"false"`
+	} `yaml:"migrations"`
+	Media struct {
+		Backend    string `yaml:"backend" env-default:"local"`
+		LocalDir   string `yaml:"local_dir" env-default:"media"`
+		MaxSize    int64  `yaml:"max_size" env-default:"10485760"`
+		MaxWidth   int    `yaml:"max_width" env-default:"8000"`
+		MaxHeight  int    `yaml:"max_height" env-default:"8000"`
+		URLTTL     int    `yaml:"url_ttl" env-default:"3600"`
+		SigningKey string `yaml:"signing_key" secret:"true"`
+		S3         struct {
+			Endpoint  string `yaml:"endpoint"`
+			Region    string `yaml:"region"`
+			Bucket    string `yaml:"bucket"`
+			AccessKey string `yaml:"access_key" secret:"true"`
+			SecretKey string `yaml:"secret_key" secret:"true"`
+			UseSSL    bool   `yaml:"use_ssl"`
+		} `yaml:"s3"`
+	} `yaml:"media"`
+}
+
+const (
+	DefaultPath = "config.yaml"
+
+	pathEnv    = "APP_CONFIG"
+	profileEnv = "APP_PROFILE"
+)
+
+var (
+	mu       sync.Mutex
+	instance *Config
+	source   struct{ path, profile string }
+)
+
+// Load reads the configuration from path, overlays the profile file next to
+// it when profile is set, applies APP_* environment variables and validates
+// the result. The loaded configuration is the one GetConfig returns.
+func Load(path, profile string) (*Config, error) {
+	cfg, err := read(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	instance = cfg
+	source.path, source.profile = path, profile
+	return cfg, nil
 }
 
-var instance *Config
-var once sync.Once
+// Reload reads the configuration again from where Load found it. The result
+// is returned to the caller and does not replace the one GetConfig returns,
+// only settings that are safe to change at runtime should be applied from it.
+func Reload() (*Config, error) {
+	mu.Lock()
+	path, profile := source.path, source.profile
+	mu.Unlock()
+	return read(path, profile)
+}
 
+// GetConfig returns the configuration given to Load. When Load was not called
+// it loads from APP_CONFIG and APP_PROFILE, or config.yaml, and exits on error.
 func GetConfig() *Config {
-	once.Do(func() {
-		logger := logging.GetLogger()
-		instance = &Config{}
-		if err := cleanenv.ReadConfig("config.yaml", instance); err != nil {
-			help, _ := cleanenv.GetDescription(instance, nil)
-			logger.Info(help)
-			logger.Fatal(err)
-		}
-	})
-	return instance
+	mu.Lock()
+	cfg := instance
+	mu.Unlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	path := os.Getenv(pathEnv)
+	if path == "" {
+		path = DefaultPath
+	}
+	cfg, err := Load(path, os.Getenv(profileEnv))
+	if err != nil {
+		logging.GetLogger().Fatal(err)
+	}
+	return cfg
+}
+
+// PathFromEnv returns the configuration path and profile set in the
+// environment, falling back to the given defaults.
+func PathFromEnv(path, profile string) (string, string) {
+	if env := os.Getenv(pathEnv); env != "" {
+		path = env
+	}
+	if env := os.Getenv(profileEnv); env != "" {
+		profile = env
+	}
+	return path, profile
 }
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "APP_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// read builds a configuration in layers: env-default tags, the base file,
+// the profile overlay and finally APP_* environment variables, then resolves
+// secret references. Every problem found on the way is reported together.
+func read(path, profile string) (*Config, error) {
+	cfg := &Config{}
+	var problems []error
+
+	walk(reflect.ValueOf(cfg).Elem(), nil, func(field reflect.Value, tag reflect.StructTag, keys []string) {
+		if def, ok := tag.Lookup("env-default"); ok {
+			if err := setValue(field, def); err != nil {
+				problems = append(problems, fmt.Errorf("default of %s: %w", strings.Join(keys, "."), err))
+			}
+		}
+	})
+
+	if err := decodeFile(path, cfg); err != nil {
+		problems = append(problems, err)
+	}
+	if profile != "" {
+		if err := decodeFile(ProfilePath(path, profile), cfg); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	walk(reflect.ValueOf(cfg).Elem(), nil, func(field reflect.Value, tag reflect.StructTag, keys []string) {
+		name := EnvName(keys)
+		if raw, ok := os.LookupEnv(name); ok {
+			if err := setValue(field, raw); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	})
+
+	problems = append(problems, resolveSecrets(cfg)...)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(problems...))
+	}
+	return cfg, nil
+}
+
+// ProfilePath returns the overlay of path for profile, config.prod.yaml for
+// config.yaml and the prod profile.
+func ProfilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// decodeFile decodes a YAML file over cfg. Keys present in the file replace
+// the current values, maps included, so an overlay can clear or shrink a map
+// of the base file. Unknown keys are reported so typos do not go unnoticed.
+func decodeFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(document.Content) > 0 {
+		clearMaps(document.Content[0], reflect.ValueOf(cfg).Elem())
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			problems := make([]error, len(typeErr.Errors))
+			for i, message := range typeErr.Errors {
+				problems[i] = fmt.Errorf("%s: %s", path, message)
+			}
+			return errors.Join(problems...)
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// clearMaps resets the map settings of v that node sets, decoding would
+// otherwise merge their entries into the ones of a previous layer.
+func clearMaps(node *yaml.Node, v reflect.Value) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	t := v.Type()
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		for j := 0; j < t.NumField(); j++ {
+			sf := t.Field(j)
+			name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(sf.Name)
+			}
+			if !sf.IsExported() || name != key {
+				continue
+			}
+			switch sf.Type.Kind() {
+			case reflect.Map:
+				v.Field(j).Set(reflect.Zero(sf.Type))
+			case reflect.Struct:
+				clearMaps(value, v.Field(j))
+			}
+		}
+	}
+}
+
+// EnvName returns the environment variable that overrides the setting at
+// keys, APP_STORAGE_HOST for storage.host.
+func EnvName(keys []string) string {
+	return envPrefix + strings.ToUpper(strings.Join(keys, "_"))
+}
+
+// Usage writes every environment variable with its default value.
+func Usage(w io.Writer) {
+	walk(reflect.ValueOf(&Config{}).Elem(), nil, func(field reflect.Value, tag reflect.StructTag, keys []string) {
+		line := "  " + EnvName(keys) + " " + field.Type().String()
+		if def, ok := tag.Lookup("env-default"); ok {
+			line += fmt.Sprintf(" (default %q)", def)
+		}
+		fmt.Fprintln(w, line)
+	})
+}
+
+// walk calls fn for every setting of v with the YAML keys leading to it.
+func walk(v reflect.Value, keys []string, fn func(field reflect.Value, tag reflect.StructTag, keys []string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if key == "" {
+			key = strings.ToLower(sf.Name)
+		}
+		fieldKeys := append(append([]string(nil), keys...), key)
+		if sf.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), fieldKeys, fn)
+			continue
+		}
+		fn(v.Field(i), sf.Tag, fieldKeys)
+	}
+}
+
+// setValue parses raw into field. Lists are comma separated and maps are
+// written as key=value pairs separated by commas.
+func setValue(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		items := splitList(raw)
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		for _, item := range splitList(raw) {
+			key, value, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("%q is not a key=value pair", item)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(elem, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), elem)
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
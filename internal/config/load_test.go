@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// base is the smallest configuration passing validation.
+const base = `
+is_debug: true
+storage:
+  host: localhost
+  database: blog
+  username: blog
+jwt:
+  secret: dev-only-secret
+media:
+  signing_key: dev-only-signing-key
+listen:
+  port: 8001
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestProfilePath(t *testing.T) {
+	tests := []struct {
+		path, profile, want string
+	}{
+		{"config.yaml", "prod", "config.prod.yaml"},
+		{"/etc/blog/config.yml", "staging", "/etc/blog/config.staging.yml"},
+		{"conf.d/app", "dev", "conf.d/app.dev"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ProfilePath(tt.path, tt.profile))
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"is_debug"}, "APP_IS_DEBUG"},
+		{[]string{"storage", "host"}, "APP_STORAGE_HOST"},
+		{[]string{"storage", "pool", "max_conns"}, "APP_STORAGE_POOL_MAX_CONNS"},
+		{[]string{"cache", "refresh_tokens", "max_entries"}, "APP_CACHE_REFRESH_TOKENS_MAX_ENTRIES"},
+		{[]string{"cors", "allowed_origins"}, "APP_CORS_ALLOWED_ORIGINS"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, EnvName(tt.keys))
+	}
+
+	var usage bytes.Buffer
+	Usage(&usage)
+	for _, tt := range tests {
+		assert.Contains(t, usage.String(), "  "+tt.want+" ")
+	}
+	assert.Contains(t, usage.String(), `APP_STORAGE_REPLICAS []string`)
+	assert.Contains(t, usage.String(), `APP_LISTEN_SHUTDOWN_TIMEOUT time.Duration (default "20s")`)
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  interface{}
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{"duration", new(time.Duration), "1m30s", 90 * time.Second, false},
+		{"zero duration", new(time.Duration), "0s", time.Duration(0), false},
+		{"duration without unit", new(time.Duration), "30", nil, true},
+		{"int", new(int32), "12", int32(12), false},
+		{"int overflow", new(int32), "4294967296", nil, true},
+		{"bool", new(bool), "true", true, false},
+		{"float", new(float64), "0.25", 0.25, false},
+		{"list", new([]string), " a.example , b.example,", []string{"a.example", "b.example"}, false},
+		{"durations by key", new(map[string]time.Duration), "GET /=1s, POST /x=2s",
+			map[string]time.Duration{"GET /": time.Second, "POST /x": 2 * time.Second}, false},
+		{"pair without value", new(map[string]string), "internal/apps", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := reflect.ValueOf(tt.target).Elem()
+			err := setValue(field, tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, field.Interface())
+		})
+	}
+}
+
+func TestReadLayers(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", base+`
+logging:
+  level: info
+  packages:
+    internal/apps: debug
+`)
+	writeFile(t, dir, "config.prod.yaml", `
+listen:
+  port: 8002
+logging:
+  level: warn
+`)
+	t.Setenv("APP_LISTEN_PORT", "8003")
+	t.Setenv("APP_CORS_ALLOWED_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("APP_STORAGE_POOL_MAX_CONNS", "20")
+	t.Setenv("APP_HTTP_ROUTE_TIMEOUTS", "GET /media/=0s")
+
+	cfg, err := read(path, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", cfg.Storage.Backend, "defaults fill what no layer sets")
+	assert.Equal(t, 20*time.Second, cfg.Listen.ShutdownTimeout)
+	assert.Equal(t, "localhost", cfg.Storage.Host, "the base file applies")
+	assert.Equal(t, map[string]string{"internal/apps": "debug"}, cfg.Logging.Packages)
+	assert.Equal(t, "warn", cfg.Logging.Level, "the profile overrides the base file")
+	assert.Equal(t, "8003", cfg.Listen.Port, "the environment overrides the profile")
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, int32(20), cfg.Storage.Pool.MaxConns)
+	assert.Equal(t, map[string]time.Duration{"GET /media/": 0}, cfg.HTTP.RouteTimeouts)
+
+	cfg, err = read(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "info", cfg.Logging.Level, "without a profile the overlay is not read")
+
+	_, err = read(path, "staging")
+	assert.ErrorContains(t, err, "config.staging.yaml", "a missing profile is an error")
+
+	writeFile(t, dir, "config.quiet.yaml", `
+logging:
+  packages: {}
+`)
+	cfg, err = read(path, "quiet")
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Logging.Packages, "an overlay replaces a map instead of merging into it")
+	assert.Equal(t, "info", cfg.Logging.Level, "settings next to the map are kept")
+
+	writeFile(t, dir, "config.apps.yaml", `
+logging:
+  packages:
+    pkg/client: trace
+`)
+	cfg, err = read(path, "apps")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pkg/client": "trace"}, cfg.Logging.Packages)
+}
+
+func TestReadRejectsUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", base+`
+lister:
+  port: 8000
+mongodb:
+  hots: db.internal
+`)
+	_, err := read(path, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field lister not found")
+	assert.Contains(t, err.Error(), "field hots not found")
+}
+
+func TestReadReportsEveryProblem(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", `
+is_debug: false
+storage:
+  host: localhost
+  port: x
+  username: blog
+jwt:
+  secret: secret
+media:
+  signing_key: secret
+logging:
+  level: loud
+`)
+	t.Setenv("APP_CACHE_TTL", "soon")
+
+	_, err := read(path, "")
+	require.Error(t, err)
+	message := err.Error()
+	assert.True(t, strings.HasPrefix(message, "invalid configuration:\n"))
+	for _, want := range []string{
+		"APP_CACHE_TTL",
+		`storage.port must be a port number, got "x"`,
+		"storage.database is required",
+		"jwt.secret is a well known default",
+		"media.signing_key must differ from jwt.secret",
+		"logging.level",
+	} {
+		assert.Contains(t, message, want)
+	}
+}
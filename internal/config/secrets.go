@@ -0,0 +1,116 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// SecretProvider resolves a secret reference such as vault://kv/blog#jwt.
+// It receives the part after the scheme separator.
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]SecretProvider{
+		"file": SecretProviderFunc(readSecretFile),
+		"env":  SecretProviderFunc(readSecretEnv),
+	}
+)
+
+// secretResolveTimeout bounds the time remote providers get at load time.
+const secretResolveTimeout = 10 * time.Second
+
+// RegisterSecretProvider makes values written as scheme://ref resolve through
+// provider. It must be called before Load.
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+// readSecretFile reads Docker and Kubernetes secrets mounted as files, the
+// trailing newline editors add is not part of the secret.
+func readSecretFile(_ context.Context, path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func readSecretEnv(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolveSecrets replaces every string setting written as scheme://ref with
+// a registered scheme by the value of the secret. Other URLs are left alone.
+func resolveSecrets(cfg *Config) []error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	var problems []error
+	walk(reflect.ValueOf(cfg).Elem(), nil, func(field reflect.Value, tag reflect.StructTag, keys []string) {
+		if field.Kind() != reflect.String {
+			return
+		}
+		scheme, ref, ok := strings.Cut(field.String(), "://")
+		if !ok {
+			return
+		}
+		provider, ok := providers[scheme]
+		if !ok {
+			return
+		}
+		value, err := provider.Resolve(ctx, ref)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: resolving %s secret: %w", strings.Join(keys, "."), scheme, err))
+			return
+		}
+		field.SetString(value)
+	})
+	return problems
+}
+
+// Redacted returns a copy of cfg with every setting tagged secret replaced,
+// safe to log.
+func (cfg Config) Redacted() Config {
+	walk(reflect.ValueOf(&cfg).Elem(), nil, func(field reflect.Value, tag reflect.StructTag, keys []string) {
+		if tag.Get("secret") == "true" && field.Kind() == reflect.String && field.String() != "" {
+			field.SetString(redacted)
+		}
+	})
+	return cfg
+}
+
+// String formats the redacted configuration, so logging a Config never
+// prints its secrets.
+func (cfg Config) String() string {
+	type plain Config
+	return fmt.Sprintf("%+v", plain(cfg.Redacted()))
+}
+
+func (cfg Config) GoString() string {
+	return cfg.String()
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := writeFile(t, dir, "jwt_secret", "s3cr3t-from-file\r\n")
+	t.Setenv("TEST_SIGNING_KEY", "signing-key-from-env")
+	RegisterSecretProvider("test", SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		if ref == "kv/blog#s3" {
+			return "from-provider", nil
+		}
+		return "", errors.New("no such secret")
+	}))
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, "test")
+		providersMu.Unlock()
+	})
+
+	var cfg Config
+	cfg.JWT.Secret = "file://" + secretFile
+	cfg.Media.SigningKey = "env://TEST_SIGNING_KEY"
+	cfg.Media.S3.SecretKey = "test://kv/blog#s3"
+	cfg.Media.S3.Endpoint = "https://s3.example"
+	cfg.Tracing.Endpoint = "unknown://collector:4318"
+	cfg.Storage.Password = "plain"
+	require.Empty(t, resolveSecrets(&cfg))
+
+	assert.Equal(t, "s3cr3t-from-file", cfg.JWT.Secret, "the trailing newline is trimmed")
+	assert.Equal(t, "signing-key-from-env", cfg.Media.SigningKey)
+	assert.Equal(t, "from-provider", cfg.Media.S3.SecretKey)
+	assert.Equal(t, "https://s3.example", cfg.Media.S3.Endpoint, "URLs are not secrets")
+	assert.Equal(t, "unknown://collector:4318", cfg.Tracing.Endpoint, "unknown schemes are left intact")
+	assert.Equal(t, "plain", cfg.Storage.Password)
+
+	cfg = Config{}
+	cfg.JWT.Secret = "env://TEST_MISSING_SECRET"
+	cfg.Media.SigningKey = "file://" + dir + "/missing"
+	cfg.Media.S3.SecretKey = "test://kv/other"
+	problems := resolveSecrets(&cfg)
+	require.Len(t, problems, 3, "every failure is reported")
+	assert.ErrorContains(t, problems[0], "jwt.secret: resolving env secret: environment variable TEST_MISSING_SECRET is not set")
+	assert.ErrorContains(t, problems[1], "media.signing_key: resolving file secret")
+	assert.ErrorContains(t, problems[2], "media.s3.secret_key: resolving test secret: no such secret")
+	assert.Equal(t, "env://TEST_MISSING_SECRET", cfg.JWT.Secret, "an unresolved reference is kept")
+}
+
+// secretConfig sets every setting tagged secret to a value naming it.
+func secretConfig(t *testing.T) (Config, []string) {
+	var cfg Config
+	var values []string
+	walk(reflect.ValueOf(&cfg).Elem(), nil, func(field reflect.Value, tag reflect.StructTag, keys []string) {
+		if tag.Get("secret") == "true" {
+			value := "leak-" + strings.Join(keys, "-")
+			field.SetString(value)
+			values = append(values, value)
+		}
+	})
+	require.NotEmpty(t, values)
+	cfg.Storage.Host = "db.internal"
+	return cfg, values
+}
+
+func TestRedacted(t *testing.T) {
+	cfg, values := secretConfig(t)
+	redactedCfg := cfg.Redacted()
+	assert.Equal(t, redacted, redactedCfg.JWT.Secret)
+	assert.Equal(t, redacted, redactedCfg.Media.S3.AccessKey)
+	assert.Equal(t, "db.internal", redactedCfg.Storage.Host)
+	assert.Equal(t, "leak-jwt-secret", cfg.JWT.Secret, "the original is left alone")
+
+	var empty Config
+	assert.Empty(t, empty.Redacted().JWT.Secret, "unset secrets stay empty")
+
+	for _, format := range []string{"%v", "%+v", "%s", "%#v"} {
+		for _, formatted := range []string{fmt.Sprintf(format, cfg), fmt.Sprintf(format, &cfg)} {
+			assert.Contains(t, formatted, "db.internal", format)
+			assert.Contains(t, formatted, redacted, format)
+			for _, value := range values {
+				assert.NotContains(t, formatted, value, format)
+			}
+		}
+	}
+}
+
+func TestWeakSecret(t *testing.T) {
+	tests := []struct {
+		secret string
+		want   string
+	}{
+		{"$3cr3t", "a well known default"},
+		{"dev-only-secret-do-not-use-in-production", "a well known default"},
+		{"My-Very-Long-ChangeMe-Value-For-Production-Use", "a well known default"},
+		{"tooshort", "shorter than 32 bytes"},
+		{strings.Repeat("ab", 20), "made of too few distinct characters"},
+		{"q7Vd2kLm9XpR4tZs8BnW1cYh6FjG3eUa", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, weakSecret(tt.secret), tt.secret)
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validate returns every problem of cfg rather than stopping at the first,
+// so a broken deployment can be fixed in one go.
+func (cfg *Config) validate() []error {
+	var problems []error
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		problem("%s must be one of %v, got %q", key, allowed, value)
+	}
+	port := func(key, value string) {
+		if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+			problem("%s must be a port number, got %q", key, value)
+		}
+	}
+
+	oneOf("storage.backend", cfg.Storage.Backend, "postgres", "mongodb")
+	if cfg.Storage.Backend == "mongodb" {
+		if cfg.MongoDB.Host == "" || cfg.MongoDB.Database == "" {
+			problem("mongodb.host and mongodb.database are required for the mongodb backend")
+		}
+		port("mongodb.port", cfg.MongoDB.Port)
+	}
+	if cfg.Storage.Host == "" {
+		problem("storage.host is required")
+	}
+	port("storage.port", cfg.Storage.Port)
+	if cfg.Storage.Database == "" {
+		problem("storage.database is required")
+	}
+	if cfg.Storage.Username == "" {
+		problem("storage.username is required")
+	}
+	oneOf("storage.sslmode", cfg.Storage.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	if (cfg.Storage.SSLCert == "") != (cfg.Storage.SSLKey == "") {
+		problem("storage.sslcert and storage.sslkey must be set together")
+	}
+	if cfg.Storage.ConnectTimeout <= 0 {
+		problem("storage.connect_timeout must be positive")
+	}
+	if cfg.Storage.StatementTimeout < 0 {
+		problem("storage.statement_timeout must not be negative")
+	}
+	if cfg.Storage.Pool.MaxConns < 1 {
+		problem("storage.pool.max_conns must be at least 1")
+	}
+	if cfg.Storage.Pool.MinConns < 0 || cfg.Storage.Pool.MinConns > cfg.Storage.Pool.MaxConns {
+		problem("storage.pool.min_conns must be between 0 and max_conns")
+	}
+	if len(cfg.Storage.Replicas) > 0 && cfg.Storage.ReplicaCheckInterval <= 0 {
+		problem("storage.replica_check_interval must be positive")
+	}
+	if cfg.Storage.ReplicaMaxLag < 0 {
+		problem("storage.replica_max_lag must not be negative")
+	}
+	if cfg.Storage.Retry.Attempts < 1 {
+		problem("storage.retry.attempts must be at least 1")
+	}
+	if cfg.Storage.Retry.InitialBackoff <= 0 || cfg.Storage.Retry.MaxBackoff < cfg.Storage.Retry.InitialBackoff {
+		problem("storage.retry backoffs must be positive and max_backoff not below initial_backoff")
+	}
+	if cfg.JWT.Secret == "" {
+		problem("jwt.secret is required")
+	} else if reason := weakSecret(cfg.JWT.Secret); reason != "" && !cfg.IsDebug {
+		problem("jwt.secret is %s, it is only accepted with is_debug", reason)
+	}
+
+	oneOf("cache.backend", cfg.Cache.Backend, "freecache", "lru", "tinylfu")
+	cacheSize := func(key string, size, maxEntries int) {
+		switch {
+		case cfg.Cache.Backend == "freecache" && size < 512*1024:
+			// freecache needs at least 512KB
+			problem("%s.size must be at least 524288 bytes", key)
+		case size < 0 || maxEntries < 0:
+			problem("%s.size and %s.max_entries must not be negative", key, key)
+		case size == 0 && maxEntries == 0:
+			problem("%s.size or %s.max_entries must be set", key, key)
+		}
+	}
+	cacheSize("cache", cfg.Cache.Size, cfg.Cache.MaxEntries)
+	cacheSize("cache.refresh_tokens", cfg.Cache.RefreshTokens.Size, cfg.Cache.RefreshTokens.MaxEntries)
+	if cfg.Cache.TTL < time.Second {
+		problem("cache.ttl must be at least 1s")
+	}
+	if cfg.Cache.NegativeTTL < 0 {
+		problem("cache.negative_ttl must not be negative")
+	}
+
+	oneOf("listen.type", cfg.Listen.Type, "port", "tcp", "sock")
+	if cfg.Listen.Type != "sock" {
+		port("listen.port", cfg.Listen.Port)
+	}
+	if cfg.Listen.DrainDelay < 0 {
+		problem("listen.drain_delay must not be negative")
+	}
+	if cfg.Listen.ShutdownTimeout <= 0 {
+		problem("listen.shutdown_timeout must be positive")
+	}
+	if len(cfg.CORS.AllowedOrigins) == 0 {
+		problem("cors.allowed_origins must not be empty")
+	}
+
+	if cfg.HTTP.RequestTimeout < 0 {
+		problem("http.request_timeout must not be negative")
+	}
+	for route, timeout := range cfg.HTTP.RouteTimeouts {
+		if timeout < 0 {
+			problem("http.route_timeouts[%s] must not be negative", route)
+		}
+	}
+
+	oneOf("logging.format", cfg.Logging.Format, "text", "json")
+	if _, err := logrus.ParseLevel(cfg.Logging.Level); err != nil {
+		problem("logging.level: %v", err)
+	}
+	for pkg, level := range cfg.Logging.Packages {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			problem("logging.packages[%s]: %v", pkg, err)
+		}
+	}
+	for _, output := range cfg.Logging.Outputs {
+		oneOf("logging.outputs", output, "stdout", "stderr", "file")
+		if output == "file" && cfg.Logging.File.Path == "" {
+			problem("logging.file.path is required when logging to a file")
+		}
+	}
+	if cfg.Logging.Sampling.Initial < 0 || cfg.Logging.Sampling.Thereafter < 0 {
+		problem("logging.sampling values must not be negative")
+	}
+
+	oneOf("tracing.exporter", cfg.Tracing.Exporter, "none", "otlp", "stdout", "file")
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		problem("tracing.sample_ratio must be between 0 and 1, got %v", cfg.Tracing.SampleRatio)
+	}
+
+	oneOf("media.backend", cfg.Media.Backend, "local", "s3")
+	if cfg.Media.Backend == "s3" && cfg.Media.S3.Bucket == "" {
+		problem("media.s3.bucket is required for the s3 backend")
+	}
+	if cfg.Media.MaxSize <= 0 || cfg.Media.MaxWidth <= 0 || cfg.Media.MaxHeight <= 0 {
+		problem("media.max_size, max_width and max_height must be positive")
+	}
+	if cfg.Media.URLTTL <= 0 {
+		problem("media.url_ttl must be positive")
+	}
+	if cfg.Media.SigningKey == "" {
+		problem("media.signing_key is required")
+	} else if cfg.Media.SigningKey == cfg.JWT.Secret {
+		problem("media.signing_key must differ from jwt.secret")
+	} else if reason := weakSecret(cfg.Media.SigningKey); reason != "" && !cfg.IsDebug {
+		problem("media.signing_key is %s, it is only accepted with is_debug", reason)
+	}
+	return problems
+}
+
+// minSecretLength is the size of an HS256 key, shorter secrets can be brute forced.
+const minSecretLength = 32
+
+// knownSecrets are values found in examples and tutorials.
+var knownSecrets = []string{"$3cr3t", "secret", "changeme", "change-me", "jwt-secret", "your-256-bit-secret", "dev-only-secret"}
+
+// weakSecret tells why secret must not sign production tokens, or returns
+// an empty string.
+func weakSecret(secret string) string {
+	lower := strings.ToLower(secret)
+	for _, known := range knownSecrets {
+		if strings.Contains(lower, known) {
+			return "a well known default"
+		}
+	}
+	if len(secret) < minSecretLength {
+		return fmt.Sprintf("shorter than %d bytes", minSecretLength)
+	}
+	distinct := make(map[rune]struct{})
+	for _, r := range secret {
+		distinct[r] = struct{}{}
+	}
+	if len(distinct) < 8 {
+		return "made of too few distinct characters"
+	}
+	return ""
+}
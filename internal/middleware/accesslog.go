@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"go.mod/pkg/logging"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AccessLog writes one line per request once the response is complete.
+// Requests are labelled with the route pattern they matched so that paths
+// with parameters are grouped together.
+func AccessLog(router *httprouter.Router) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			// resolved before serving, http.FileServer rewrites the URL path
+			route := RoutePattern(router, r)
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			fields := map[string]interface{}{
+				"route":      route,
+				"status":     status,
+				"bytes":      rw.bytes,
+				"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if s := stateFrom(r.Context()); s != nil && s.userID != "" {
+				fields["user_id"] = s.userID
+			}
+			logging.FromContext(r.Context()).WithFields(fields).Info("request completed")
+		})
+	}
+}
+
+// RoutePattern returns the pattern of the route that serves r, or
+// "unmatched" when no route does.
+func RoutePattern(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		return "unmatched"
+	}
+	pattern := r.URL.Path
+	for _, p := range params {
+		if strings.HasPrefix(p.Value, "/") {
+			pattern = strings.TrimSuffix(pattern, p.Value) + "/*" + p.Key
+			continue
+		}
+		pattern = strings.Replace(pattern, "/"+p.Value, "/:"+p.Key, 1)
+	}
+	return pattern
+}
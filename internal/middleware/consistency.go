@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"go.mod/pkg/client/postgresql"
+	"net/http"
+)
+
+// ReadYourWrites sends the reads of a request to the primary database once
+// the request has written, so it never reads from a replica that has not
+// caught up with its own changes yet.
+func ReadYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(postgresql.TrackWrites(r.Context())))
+	})
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/rs/cors"
+	"net/http"
+	"sync/atomic"
+)
+
+// CORS applies a cross-origin policy whose allowed origins can be replaced
+// while the server runs.
+type CORS struct {
+	policy atomic.Pointer[cors.Cors]
+}
+
+func NewCORS(origins []string) *CORS {
+	c := &CORS{}
+	c.SetOrigins(origins)
+	return c
+}
+
+// SetOrigins replaces the allowed origins, "*" allows any origin.
+func (c *CORS) SetOrigins(origins []string) {
+	c.policy.Store(cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodHead,
+		},
+		AllowedHeaders: []string{
+			"Accept", "Authorization", "Content-Type", "If-Match", "If-None-Match", RequestIDHeader, "traceparent",
+		},
+		ExposedHeaders: []string{"ETag", RequestIDHeader},
+	}))
+}
+
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.policy.Load().ServeHTTP(w, r, next.ServeHTTP)
+	})
+}
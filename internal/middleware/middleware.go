@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"go.mod/pkg/logging"
+	"net/http"
+)
+
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h so that the first middleware is the outermost one.
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+type stateKey struct{}
+
+// requestState is shared by the middlewares of one request. Handlers deeper in
+// the chain fill it in, the access log reads it once the response is written.
+type requestState struct {
+	requestID string
+	userID    string
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	if s, ok := ctx.Value(stateKey{}).(*requestState); ok {
+		return s
+	}
+	return nil
+}
+
+// RequestIDFromContext returns the id assigned to the request by RequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	if s := stateFrom(ctx); s != nil {
+		return s.requestID
+	}
+	return ""
+}
+
+// WithUserID records the authenticated user for the access log and returns
+// a context whose logger carries the user_id field.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	if s := stateFrom(ctx); s != nil {
+		s.userID = userID
+	}
+	logger := logging.FromContext(ctx).WithFields(map[string]interface{}{"user_id": userID})
+	return logging.ContextWithLogger(ctx, logger)
+}
+
+// responseWriter remembers the status and the number of bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"fmt"
+	"go.mod/internal/apperror"
+	"go.mod/pkg/logging"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panic in a handler into a 500 problem response and logs
+// the panic value with its stack trace. A handler that already sent its
+// headers keeps its response, a problem body would only corrupt it.
+// http.ErrAbortHandler is re-raised so the server can abort the connection
+// as intended.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			logging.FromContext(r.Context()).
+				WithFields(map[string]interface{}{"stack": string(debug.Stack())}).
+				Errorf("panic: %v", rec)
+			if rw.status != 0 {
+				return
+			}
+			apperror.WriteProblem(w, r, fmt.Errorf("panic: %v", rec))
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.mod/internal/apperror"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "before the headers",
+			handler:     func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			status:      http.StatusInternalServerError,
+			contentType: apperror.ProblemContentType,
+		},
+		{
+			name: "after the headers",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`[{"id": 1},`))
+				panic("boom")
+			},
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `[{"id": 1},`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			Recover(tt.handler).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/products/", nil))
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.Equal(t, tt.contentType, recorder.Header().Get("Content-Type"))
+			if tt.body != "" {
+				assert.Equal(t, tt.body, recorder.Body.String(), "nothing is appended to a started response")
+			}
+		})
+	}
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
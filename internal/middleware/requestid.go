@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.mod/pkg/logging"
+	"net/http"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestID reuses the X-Request-ID sent by the client or a proxy, or
+// generates one, echoes it in the response and stores a logger carrying it
+// in the request context.
+func RequestID(logger *logging.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithValue(r.Context(), stateKey{}, &requestState{requestID: id})
+			ctx = logging.ContextWithLogger(ctx, logger.WithFields(map[string]interface{}{
+				"request_id": id,
+				"method":     r.Method,
+				"path":       r.URL.Path,
+			}))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"time"
+)
+
+// writeMargin leaves time to read the rest of the body and write the error
+// response once a deadline passed.
+const writeMargin = 5 * time.Second
+
+// Timeout bounds the request context with a deadline so that the queries a
+// handler runs are cancelled when it is exceeded. routes overrides the
+// fallback per route, keyed by "METHOD /pattern" or "/pattern". A zero
+// duration disables the deadline. The connection read and write deadlines are
+// moved along so the server's ReadTimeout and WriteTimeout do not cut a longer
+// route, such as an upload still streaming its body, short.
+func Timeout(router *httprouter.Router, fallback time.Duration, routes map[string]time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := routeTimeout(RoutePattern(router, r), r.Method, fallback, routes)
+			rc := http.NewResponseController(w)
+			if timeout <= 0 {
+				rc.SetReadDeadline(time.Time{})
+				rc.SetWriteDeadline(time.Time{})
+				next.ServeHTTP(w, r)
+				return
+			}
+			deadline := time.Now().Add(timeout + writeMargin)
+			rc.SetReadDeadline(deadline)
+			rc.SetWriteDeadline(deadline)
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func routeTimeout(route, method string, fallback time.Duration, routes map[string]time.Duration) time.Duration {
+	if d, ok := routes[method+" "+route]; ok {
+		return d
+	}
+	if d, ok := routes[route]; ok {
+		return d
+	}
+	return fallback
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	routes := map[string]time.Duration{
+		"POST /products/attachments/": time.Minute,
+		"/products/attachments/":      30 * time.Second,
+		"/products/export/":           0,
+	}
+	tests := []struct {
+		name   string
+		route  string
+		method string
+		want   time.Duration
+	}{
+		{"method and pattern", "/products/attachments/", http.MethodPost, time.Minute},
+		{"pattern only", "/products/attachments/", http.MethodGet, 30 * time.Second},
+		{"disabled", "/products/export/", http.MethodGet, 0},
+		{"fallback", "/products/", http.MethodGet, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, routeTimeout(tt.route, tt.method, 10*time.Second, routes))
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	var remaining time.Duration
+	var hasDeadline bool
+	record := func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, hasDeadline = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	}
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodGet, "/products/id/:id", record)
+	router.HandlerFunc(http.MethodGet, "/products/export/", record)
+	handler := Timeout(router, 10*time.Second, map[string]time.Duration{
+		"GET /products/id/:id": time.Minute,
+		"/products/export/":    0,
+	})(router)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/id/7", nil))
+	require.True(t, hasDeadline)
+	assert.InDelta(t, time.Minute, remaining, float64(time.Second), "the route pattern selects the budget")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/export/", nil))
+	assert.False(t, hasDeadline, "a zero budget disables the deadline")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/", nil))
+	assert.False(t, hasDeadline, "unmatched requests are not routed")
+}
+
+func TestTimeoutProblems(t *testing.T) {
+	wait := apperror.Middleware(func(w http.ResponseWriter, r *http.Request) error {
+		<-r.Context().Done()
+		return r.Context().Err()
+	})
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodGet, "/products/", wait)
+	handler := Timeout(router, 10*time.Millisecond, nil)(router)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/products/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), apperror.RequestTimeout.Code, "an exceeded deadline is a timeout")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/products/", nil).WithContext(ctx))
+	assert.Equal(t, apperror.StatusClientClosedRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), apperror.RequestCanceled.Code, "a gone client is not a timeout")
+}
+
+func TestTimeoutExtendsReadDeadline(t *testing.T) {
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodPost, "/products/attachments/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestTimeout)
+			return
+		}
+		w.Write(body)
+	})
+	server := httptest.NewUnstartedServer(Timeout(router, 10*time.Millisecond, map[string]time.Duration{
+		"POST /products/attachments/": time.Minute,
+	})(router))
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.Write([]byte("first "))
+		time.Sleep(300 * time.Millisecond)
+		writer.Write([]byte("second"))
+		writer.Close()
+	}()
+	response, err := http.Post(server.URL+"/products/attachments/", "text/plain", reader)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode, string(body))
+	assert.Equal(t, "first second", string(body), "the body outlives the server ReadTimeout")
+}
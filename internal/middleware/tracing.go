@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"go.mod/pkg/logging"
+	"go.mod/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Tracing starts a server span for every request, continuing the trace sent
+// in the W3C traceparent header. The trace and span ids are added to the
+// request logger so log lines can be matched with their trace.
+func Tracing(router *httprouter.Router) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := RoutePattern(router, r)
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethod(r.Method),
+					semconv.HTTPRoute(route),
+					semconv.HTTPTarget(r.URL.RequestURI()),
+					attribute.String("http.user_agent", r.UserAgent()),
+				),
+			)
+			defer span.End()
+			if id := RequestIDFromContext(ctx); id != "" {
+				span.SetAttributes(attribute.String("http.request_id", id))
+			}
+
+			if sc := span.SpanContext(); sc.IsValid() {
+				ctx = logging.ContextWithLogger(ctx, logging.FromContext(ctx).WithFields(map[string]interface{}{
+					"trace_id": sc.TraceID().String(),
+					"span_id":  sc.SpanID().String(),
+				}))
+			}
+
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPStatusCode(status), semconv.HTTPResponseContentLength(rw.bytes))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE public.product;
+DROP TABLE public.category;
+DROP TABLE public.user;
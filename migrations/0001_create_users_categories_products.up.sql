@@ -0,0 +1,27 @@
+CREATE TABLE public.user
+(
+    id            SERIAL       NOT NULL PRIMARY KEY,
+    username      VARCHAR(100) NOT NULL UNIQUE,
+    email         VARCHAR(100) NOT NULL UNIQUE,
+    password_hash VARCHAR(500) NOT NULL,
+    version       INTEGER      NOT NULL DEFAULT 1
+);
+
+CREATE TABLE public.category
+(
+    id       SERIAL       NOT NULL PRIMARY KEY,
+    title    VARCHAR(100) NOT NULL UNIQUE,
+    child_id INTEGER      NOT NULL DEFAULT 0
+);
+
+CREATE TABLE public.product
+(
+    id          SERIAL       NOT NULL PRIMARY KEY,
+    title       VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT         NOT NULL,
+    owner_id    INTEGER      NOT NULL REFERENCES public.user (id) ON DELETE CASCADE,
+    category_id INTEGER      NULL REFERENCES public.category (id) ON DELETE SET NULL,
+    version     INTEGER      NOT NULL DEFAULT 1
+);
+
+CREATE INDEX product_owner_idx ON public.product (owner_id);
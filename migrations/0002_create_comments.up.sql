@@ -0,0 +1,15 @@
+CREATE TABLE public.comment
+(
+    id         SERIAL      NOT NULL PRIMARY KEY,
+    product_id INTEGER     NOT NULL REFERENCES public.product (id) ON DELETE CASCADE,
+    parent_id  INTEGER     NULL REFERENCES public.comment (id),
+    author_id  INTEGER     NOT NULL REFERENCES public.user (id) ON DELETE CASCADE,
+    body       TEXT        NOT NULL,
+    status     VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX comment_product_idx ON public.comment (product_id, created_at);
+CREATE INDEX comment_status_idx ON public.comment (status) WHERE deleted_at IS NULL;
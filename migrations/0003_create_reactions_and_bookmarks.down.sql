@@ -0,0 +1,3 @@
+DROP TABLE public.product_counter;
+DROP TABLE public.product_bookmark;
+DROP TABLE public.product_reaction;
@@ -0,0 +1,26 @@
+CREATE TABLE public.product_reaction
+(
+    product_id INTEGER     NOT NULL REFERENCES public.product (id) ON DELETE CASCADE,
+    user_id    INTEGER     NOT NULL REFERENCES public.user (id) ON DELETE CASCADE,
+    kind       VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (product_id, user_id, kind)
+);
+
+CREATE TABLE public.product_bookmark
+(
+    product_id INTEGER     NOT NULL REFERENCES public.product (id) ON DELETE CASCADE,
+    user_id    INTEGER     NOT NULL REFERENCES public.user (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (product_id, user_id)
+);
+
+CREATE INDEX product_bookmark_user_idx ON public.product_bookmark (user_id, created_at);
+
+CREATE TABLE public.product_counter
+(
+    product_id INTEGER     NOT NULL REFERENCES public.product (id) ON DELETE CASCADE,
+    kind       VARCHAR(16) NOT NULL,
+    count      INTEGER     NOT NULL DEFAULT 0 CHECK (count >= 0),
+    PRIMARY KEY (product_id, kind)
+);
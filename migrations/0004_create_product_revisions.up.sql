@@ -0,0 +1,11 @@
+CREATE TABLE public.product_revision
+(
+    product_id  INTEGER      NOT NULL REFERENCES public.product (id) ON DELETE CASCADE,
+    version     INTEGER      NOT NULL,
+    title       VARCHAR(100) NOT NULL,
+    description TEXT         NOT NULL,
+    author_id   INTEGER      NOT NULL REFERENCES public.user (id),
+    rollback_of INTEGER      NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    PRIMARY KEY (product_id, version)
+);
@@ -0,0 +1 @@
+DROP TABLE public.product_attachment;
@@ -0,0 +1,15 @@
+CREATE TABLE public.product_attachment
+(
+    id            SERIAL       NOT NULL PRIMARY KEY,
+    product_id    INTEGER      NOT NULL REFERENCES public.product (id) ON DELETE CASCADE,
+    key           VARCHAR(255) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(255) NULL,
+    filename      VARCHAR(255) NOT NULL,
+    content_type  VARCHAR(100) NOT NULL,
+    size          BIGINT       NOT NULL,
+    width         INTEGER      NOT NULL DEFAULT 0,
+    height        INTEGER      NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX product_attachment_product_idx ON public.product_attachment (product_id);
@@ -0,0 +1,9 @@
+ALTER TABLE public.comment
+    DROP CONSTRAINT comment_parent_id_fkey,
+    ADD CONSTRAINT comment_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES public.comment (id);
+
+-- fails while revisions of deleted authors are left, they are not dropped silently
+ALTER TABLE public.product_revision
+    DROP CONSTRAINT product_revision_author_id_fkey,
+    ADD CONSTRAINT product_revision_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.user (id),
+    ALTER COLUMN author_id SET NOT NULL;
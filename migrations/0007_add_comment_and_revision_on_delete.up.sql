@@ -0,0 +1,9 @@
+-- replies go with the comment they answer, a revision outlives its author
+ALTER TABLE public.comment
+    DROP CONSTRAINT comment_parent_id_fkey,
+    ADD CONSTRAINT comment_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES public.comment (id) ON DELETE CASCADE;
+
+ALTER TABLE public.product_revision
+    ALTER COLUMN author_id DROP NOT NULL,
+    DROP CONSTRAINT product_revision_author_id_fkey,
+    ADD CONSTRAINT product_revision_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.user (id) ON DELETE SET NULL;
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"go.mod/pkg/blobstore"
+	"io"
+	"sync"
+)
+
+type store struct {
+	sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewBlobStore returns an in-process BlobStore, meant as a fake for tests and local runs.
+func NewBlobStore() blobstore.BlobStore {
+	return &store{objects: make(map[string][]byte)}
+}
+
+func (s *store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	s.objects[key] = data
+	return nil
+}
+
+func (s *store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *store) Delete(ctx context.Context, key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.objects[key]; !ok {
+		return blobstore.ErrNotFound
+	}
+	delete(s.objects, key)
+	return nil
+}
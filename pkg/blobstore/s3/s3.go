@@ -0,0 +1,72 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.mod/pkg/blobstore"
+	"io"
+)
+
+type store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewBlobStore connects to any S3 compatible endpoint (AWS, MinIO, Ceph...)
+// using path-style addressing, and creates the bucket when it does not exist yet.
+func NewBlobStore(ctx context.Context, endpoint, region, bucket, accessKey, secretKey string, useSSL bool) (blobstore.BlobStore, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:       useSSL,
+		Region:       region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client due to error: %v", err)
+	}
+
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check s3 bucket due to error: %v", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: region}); err != nil {
+			return nil, fmt.Errorf("failed to create s3 bucket due to error: %v", err)
+		}
+	}
+	return &store{client: client, bucket: bucket}, nil
+}
+
+func (s *store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translate(err)
+	}
+	// GetObject is lazy, Stat forces the request so a missing key fails here
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, translate(err)
+	}
+	return object, nil
+}
+
+func (s *store) Delete(ctx context.Context, key string) error {
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		return translate(err)
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func translate(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return blobstore.ErrNotFound
+	}
+	return err
+}
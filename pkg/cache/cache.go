@@ -1,23 +1,71 @@
 package cache
 
+import "time"
+
 type Repository interface {
-	// NewIterator creates a new iterator for the cache.
+	// GetIterator returns an iterator over a snapshot of the entries taken
+	// when it is called, later changes do not affect it.
 	GetIterator() Iterator
 
 	// Get returns the value or not found error.
 	Get(uuid []byte) ([]byte, error)
 
+	// GetMulti returns the values of the keys that were found, by key.
+	GetMulti(keys [][]byte) map[string][]byte
+
 	// Set sets a key, value and expiration for a cache entry and stores it in the cache.
 	// expireIn <= 0 means no expire, but it can be evicted when cache is full.
 	Set(key []byte, val []byte, expireIn int) error
 
+	// SetMulti stores every entry with the same expiration as Set, it stops
+	// at the first entry that can not be stored.
+	SetMulti(entries []Entry, expireIn int) error
+
+	// TTL returns the time left until the entry of key expires, 0 when it
+	// does not expire, or the not found error.
+	TTL(key []byte) (time.Duration, error)
+
 	// Del deletes an item in the cache by key and returns true or false if a delete occurred.
 	Del(key []byte) (affected bool)
 
+	// DelPrefix deletes every entry whose key starts with prefix and returns
+	// how many were deleted.
+	DelPrefix(prefix []byte) int
+
+	// Clear deletes every entry.
+	Clear()
+
 	// EntryCount returns the number of items currently in the cache.
 	EntryCount() (entryCount int64)
 	// HitCount is a metric that returns number of times a key was found in the cache.
 	HitCount() int64
 	// MissCount is a metric that returns the number of times a miss occurred in the cache.
 	MissCount() int64
+	// Stats returns the counters of the cache at once.
+	Stats() Stats
+
+	// Close releases the memory held by the cache, it must not be used afterwards.
+	Close() error
+}
+
+// Stats describes the use of a cache since it was created.
+type Stats struct {
+	Entries int64   `json:"entries"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	// Evictions counts live entries dropped to make room for new ones.
+	Evictions int64 `json:"evictions"`
+	// Expired counts entries dropped because their expiration passed.
+	Expired int64 `json:"expired"`
+	// AverageAccessTime is the mean of the times entries were last read or
+	// written, an old one means most entries are rarely used.
+	AverageAccessTime time.Time `json:"average_access_time"`
+}
+
+func hitRate(hits, misses int64) float64 {
+	if lookups := hits + misses; lookups > 0 {
+		return float64(hits) / float64(lookups)
+	}
+	return 0
 }
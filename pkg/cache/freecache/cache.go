@@ -1,9 +1,11 @@
 package freecache
 
 import (
+	"bytes"
 	"github.com/coocood/freecache"
 	"go.mod/pkg/cache"
 	"sync"
+	"time"
 )
 
 type repository struct {
@@ -36,8 +38,40 @@ func NewCacheRepo(size int) cache.Repository {
 	return &repository{cache: freecache.NewCache(size)}
 }
 
+func (r *repository) Stats() cache.Stats {
+	r.Lock()
+	defer r.Unlock()
+
+	stats := cache.Stats{
+		Entries:   r.cache.EntryCount(),
+		Hits:      r.cache.HitCount(),
+		Misses:    r.cache.MissCount(),
+		HitRate:   r.cache.HitRate(),
+		Evictions: r.cache.EvacuateCount(),
+		Expired:   r.cache.ExpiredCount(),
+	}
+	if accessed := r.cache.AverageAccessTime(); accessed > 0 {
+		stats.AverageAccessTime = time.Unix(accessed, 0)
+	}
+	return stats
+}
+
+// GetIterator copies the entries while holding the lock, so the iterator
+// never observes a write in progress.
 func (r *repository) GetIterator() cache.Iterator {
-	return &iterator{r.cache.NewIterator()}
+	r.Lock()
+	defer r.Unlock()
+
+	return cache.NewSliceIterator(r.entries())
+}
+
+func (r *repository) entries() []cache.Entry {
+	var entries []cache.Entry
+	it := r.cache.NewIterator()
+	for entry := it.Next(); entry != nil; entry = it.Next() {
+		entries = append(entries, cache.Entry{Key: entry.Key, Value: entry.Value})
+	}
+	return entries
 }
 
 func (r *repository) Get(uuid []byte) ([]byte, error) {
@@ -47,6 +81,30 @@ func (r *repository) Get(uuid []byte) ([]byte, error) {
 	return got, err
 }
 
+func (r *repository) GetMulti(keys [][]byte) map[string][]byte {
+	r.Lock()
+	defer r.Unlock()
+
+	found := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if got, err := r.cache.Get(key); err == nil {
+			found[string(key)] = got
+		}
+	}
+	return found
+}
+
+func (r *repository) TTL(key []byte) (time.Duration, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	left, err := r.cache.TTL(key)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(left) * time.Second, nil
+}
+
 func (r *repository) Set(key, val []byte, expireIn int) error {
 	r.Lock()
 	defer r.Unlock()
@@ -58,9 +116,49 @@ func (r *repository) Set(key, val []byte, expireIn int) error {
 	return nil
 }
 
+func (r *repository) SetMulti(entries []cache.Entry, expireIn int) error {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, entry := range entries {
+		if err := r.cache.Set(entry.Key, entry.Value, expireIn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *repository) Del(key []byte) (affected bool) {
 	r.Lock()
 	defer r.Unlock()
 
 	return r.cache.Del(key)
 }
+
+func (r *repository) DelPrefix(prefix []byte) int {
+	r.Lock()
+	defer r.Unlock()
+
+	deleted := 0
+	for _, entry := range r.entries() {
+		if bytes.HasPrefix(entry.Key, prefix) && r.cache.Del(entry.Key) {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func (r *repository) Clear() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.cache.Clear()
+}
+
+func (r *repository) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.cache.Clear()
+	return nil
+}
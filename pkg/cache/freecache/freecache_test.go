@@ -3,8 +3,10 @@ package freecache
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.mod/pkg/cache"
 )
 
 var uuid = []byte("Lorem ipsum")
@@ -44,7 +46,7 @@ func TestRepository(t *testing.T) {
 		assert.Nil(t, entry)
 	}
 
-	missCount := repo.HitCount()
+	missCount := repo.MissCount()
 	assert.Equal(t, missCount, int64(1))
 
 	affected := repo.Del(uuid)
@@ -86,3 +88,61 @@ func TestRepositoryLoad(t *testing.T) {
 		assert.Equal(t, affected, true)
 	}
 }
+
+func TestRepositoryBulk(t *testing.T) {
+	r := NewCacheRepo(1024 * 1024)
+	err := r.SetMulti([]cache.Entry{
+		{Key: []byte("a"), Value: []byte("1")},
+		{Key: []byte("b"), Value: []byte("2")},
+	}, 60)
+	assert.NoError(t, err)
+
+	found := r.GetMulti([][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	assert.Equal(t, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, found)
+
+	ttl, err := r.TTL([]byte("a"))
+	if assert.NoError(t, err) {
+		assert.InDelta(t, 60*time.Second, ttl, float64(2*time.Second))
+	}
+	assert.NoError(t, r.Set([]byte("forever"), []byte("x"), 0))
+	ttl, err = r.TTL([]byte("forever"))
+	assert.NoError(t, err)
+	assert.Zero(t, ttl)
+	_, err = r.TTL([]byte("c"))
+	assert.Error(t, err)
+
+	stats := r.Stats()
+	assert.Equal(t, int64(3), stats.Entries)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.InDelta(t, 2.0/3, stats.HitRate, 0.001)
+	assert.False(t, stats.AverageAccessTime.IsZero())
+}
+
+func TestRepositoryPrefixAndClear(t *testing.T) {
+	r := NewCacheRepo(1024 * 1024)
+	for _, key := range []string{"product:1", "product:2", "category:1"} {
+		assert.NoError(t, r.Set([]byte(key), []byte(key), 0))
+	}
+
+	assert.Equal(t, 2, r.DelPrefix([]byte("product:")))
+	assert.Equal(t, int64(1), r.EntryCount())
+
+	r.Clear()
+	assert.Equal(t, int64(0), r.EntryCount())
+}
+
+func TestRepositoryIteratorIsSnapshot(t *testing.T) {
+	r := NewCacheRepo(1024 * 1024)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, r.Set([]byte(strconv.Itoa(i)), []byte("v"), 0))
+	}
+
+	it := r.GetIterator()
+	r.Clear()
+	seen := 0
+	for entry := it.Next(); entry != nil; entry = it.Next() {
+		seen++
+	}
+	assert.Equal(t, 10, seen)
+}
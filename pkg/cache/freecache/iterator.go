@@ -1,22 +0,0 @@
-package freecache
-
-import (
-	"github.com/coocood/freecache"
-	"go.mod/pkg/cache"
-)
-
-type iterator struct {
-	iter *freecache.Iterator
-}
-
-func (i *iterator) Next() *cache.Entry {
-	entry := i.iter.Next()
-	if entry == nil {
-		return nil
-	}
-
-	return &cache.Entry{
-		Key:   entry.Key,
-		Value: entry.Value,
-	}
-}
@@ -0,0 +1,24 @@
+package cache
+
+import "context"
+
+// Publisher announces keys whose cached entries went stale.
+type Publisher interface {
+	Publish(ctx context.Context, keys ...string) error
+}
+
+// Bus carries invalidations between the instances sharing a database.
+type Bus interface {
+	Publisher
+	// Subscribe calls handler with the keys published by any instance until
+	// ctx is done. Keys are nil when invalidations may have been missed, the
+	// handler must then drop every entry.
+	Subscribe(ctx context.Context, handler func(keys []string)) error
+}
+
+// NopPublisher drops invalidations, for single instance setups and tests.
+var NopPublisher Publisher = nopPublisher{}
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, ...string) error { return nil }
@@ -9,3 +9,21 @@ type Entry struct {
 type Iterator interface {
 	Next() *Entry
 }
+
+// SliceIterator iterates entries collected beforehand.
+type SliceIterator struct {
+	entries []Entry
+}
+
+func NewSliceIterator(entries []Entry) *SliceIterator {
+	return &SliceIterator{entries: entries}
+}
+
+func (i *SliceIterator) Next() *Entry {
+	if len(i.entries) == 0 {
+		return nil
+	}
+	entry := &i.entries[0]
+	i.entries = i.entries[1:]
+	return entry
+}
@@ -0,0 +1,327 @@
+// Package lru is an in-process cache.Repository evicting the least recently
+// used entries once a count or size bound is reached. With the TinyLFU policy
+// a new entry only replaces the ones it would evict when it was requested at
+// least as often recently, so scans and one-off keys do not flush hot entries.
+package lru
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"go.mod/pkg/cache"
+	"sync"
+	"time"
+)
+
+const (
+	LRU     = "lru"
+	TinyLFU = "tinylfu"
+)
+
+var (
+	// ErrTooLarge is returned for entries larger than MaxBytes.
+	ErrTooLarge = errors.New("lru: entry is larger than the cache")
+	// ErrRejected is returned by TinyLFU for a new entry that is requested
+	// less often than the ones it would evict, the entry is not stored.
+	ErrRejected = errors.New("lru: entry rejected by the admission policy")
+)
+
+type Options struct {
+	// MaxEntries bounds the number of entries, 0 leaves it unbounded.
+	MaxEntries int
+	// MaxBytes bounds the summed length of keys and values, 0 leaves it unbounded.
+	MaxBytes int64
+	// Policy is LRU or TinyLFU, LRU when empty.
+	Policy string
+}
+
+type entry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+	accessed time.Time
+}
+
+func (e *entry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+var _ cache.Repository = &repository{}
+
+type repository struct {
+	mu      sync.Mutex
+	options Options
+	// order holds the entries from the most to the least recently used.
+	order  *list.List
+	items  map[string]*list.Element
+	bytes  int64
+	sketch *sketch
+	now    func() time.Time
+
+	hits, misses, evictions, expired int64
+	// accessedSum adds up the access times of the entries in unix seconds.
+	accessedSum int64
+}
+
+func NewCacheRepo(options Options) cache.Repository {
+	r := &repository{
+		options: options,
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+		now:     time.Now,
+	}
+	if options.Policy == TinyLFU {
+		width := options.MaxEntries
+		if width == 0 {
+			// assume entries of about 1KB when only the size is bounded
+			width = int(options.MaxBytes / 1024)
+		}
+		r.sketch = newSketch(width)
+	}
+	return r
+}
+
+// lookup returns the live entry of key, dropping it when it expired.
+func (r *repository) lookup(key string, now time.Time) *list.Element {
+	if r.sketch != nil {
+		r.sketch.increment(key)
+	}
+	el, ok := r.items[key]
+	if !ok {
+		return nil
+	}
+	if el.Value.(*entry).expired(now) {
+		r.remove(el)
+		r.expired++
+		return nil
+	}
+	return el
+}
+
+func (r *repository) touch(el *list.Element, now time.Time) {
+	e := el.Value.(*entry)
+	r.accessedSum += now.Unix() - e.accessed.Unix()
+	e.accessed = now
+	r.order.MoveToFront(el)
+}
+
+func (r *repository) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	r.order.Remove(el)
+	delete(r.items, e.key)
+	r.bytes -= e.size()
+	r.accessedSum -= e.accessed.Unix()
+}
+
+func (r *repository) Get(key []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	el := r.lookup(string(key), now)
+	if el == nil {
+		r.misses++
+		return nil, cache.ErrNotFound
+	}
+	r.hits++
+	r.touch(el, now)
+	return append([]byte(nil), el.Value.(*entry).value...), nil
+}
+
+func (r *repository) GetMulti(keys [][]byte) map[string][]byte {
+	found := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if value, err := r.Get(key); err == nil {
+			found[string(key)] = value
+		}
+	}
+	return found
+}
+
+func (r *repository) Set(key []byte, val []byte, expireIn int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.set(string(key), val, expireIn)
+}
+
+func (r *repository) SetMulti(entries []cache.Entry, expireIn int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range entries {
+		if err := r.set(string(e.Key), e.Value, expireIn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repository) set(key string, val []byte, expireIn int) error {
+	now := r.now()
+	e := &entry{key: key, value: append([]byte(nil), val...), accessed: now}
+	if expireIn > 0 {
+		e.expireAt = now.Add(time.Duration(expireIn) * time.Second)
+	}
+	if r.options.MaxBytes > 0 && e.size() > r.options.MaxBytes {
+		return ErrTooLarge
+	}
+	if r.sketch != nil {
+		r.sketch.increment(key)
+	}
+	el, resident := r.items[key]
+	if resident {
+		r.remove(el)
+	}
+	if !r.admit(e, resident, now) {
+		return ErrRejected
+	}
+	r.items[key] = r.order.PushFront(e)
+	r.bytes += e.size()
+	r.accessedSum += now.Unix()
+	return nil
+}
+
+// admit makes room for e by evicting the least recently used entries. Unless
+// e replaces a resident entry, TinyLFU keeps the victims and drops e when any
+// live one of them was requested more often recently.
+func (r *repository) admit(e *entry, resident bool, now time.Time) bool {
+	count, size := len(r.items)+1, r.bytes+e.size()
+	full := func() bool {
+		return (r.options.MaxEntries > 0 && count > r.options.MaxEntries) ||
+			(r.options.MaxBytes > 0 && size > r.options.MaxBytes)
+	}
+	var victims []*list.Element
+	for el := r.order.Back(); el != nil && full(); el = el.Prev() {
+		victim := el.Value.(*entry)
+		if !resident && !victim.expired(now) && r.sketch != nil && r.sketch.estimate(victim.key) > r.sketch.estimate(e.key) {
+			return false
+		}
+		victims = append(victims, el)
+		count, size = count-1, size-victim.size()
+	}
+	for _, el := range victims {
+		if el.Value.(*entry).expired(now) {
+			r.expired++
+		} else {
+			r.evictions++
+		}
+		r.remove(el)
+	}
+	return true
+}
+
+func (r *repository) TTL(key []byte) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	el, ok := r.items[string(key)]
+	if !ok || el.Value.(*entry).expired(now) {
+		return 0, cache.ErrNotFound
+	}
+	if expireAt := el.Value.(*entry).expireAt; !expireAt.IsZero() {
+		return expireAt.Sub(now), nil
+	}
+	return 0, nil
+}
+
+func (r *repository) Del(key []byte) (affected bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.items[string(key)]
+	if ok {
+		r.remove(el)
+	}
+	return ok
+}
+
+func (r *repository) DelPrefix(prefix []byte) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for key, el := range r.items {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			r.remove(el)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func (r *repository) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.order.Init()
+	r.items = make(map[string]*list.Element)
+	r.bytes, r.accessedSum = 0, 0
+}
+
+// GetIterator copies the live entries while holding the lock.
+func (r *repository) GetIterator() cache.Iterator {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	entries := make([]cache.Entry, 0, len(r.items))
+	for el := r.order.Front(); el != nil; el = el.Next() {
+		if e := el.Value.(*entry); !e.expired(now) {
+			entries = append(entries, cache.Entry{Key: []byte(e.key), Value: e.value})
+		}
+	}
+	return cache.NewSliceIterator(entries)
+}
+
+func (r *repository) EntryCount() (entryCount int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.items))
+}
+
+func (r *repository) HitCount() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.hits
+}
+
+func (r *repository) MissCount() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.misses
+}
+
+func (r *repository) Stats() cache.Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := cache.Stats{
+		Entries:   int64(len(r.items)),
+		Hits:      r.hits,
+		Misses:    r.misses,
+		Evictions: r.evictions,
+		Expired:   r.expired,
+	}
+	if lookups := r.hits + r.misses; lookups > 0 {
+		stats.HitRate = float64(r.hits) / float64(lookups)
+	}
+	if len(r.items) > 0 {
+		stats.AverageAccessTime = time.Unix(r.accessedSum/int64(len(r.items)), 0)
+	}
+	return stats
+}
+
+// Close drops every entry, the repository holds nothing else.
+func (r *repository) Close() error {
+	r.Clear()
+	return nil
+}
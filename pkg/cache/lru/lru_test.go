@@ -0,0 +1,147 @@
+package lru
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/pkg/cache"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newRepo(options Options) (*repository, *time.Time) {
+	r := NewCacheRepo(options).(*repository)
+	now := time.Unix(1700000000, 0)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	r, _ := newRepo(Options{MaxEntries: 2})
+	require.NoError(t, r.Set([]byte("a"), []byte("1"), 0))
+	require.NoError(t, r.Set([]byte("b"), []byte("2"), 0))
+	_, err := r.Get([]byte("a"))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Set([]byte("c"), []byte("3"), 0))
+	_, err = r.Get([]byte("b"))
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	got, err := r.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), got)
+	assert.Equal(t, int64(1), r.Stats().Evictions)
+}
+
+func TestLRUBoundsBytes(t *testing.T) {
+	r, _ := newRepo(Options{MaxBytes: 10})
+	require.NoError(t, r.Set([]byte("a"), []byte("1234"), 0))
+	require.NoError(t, r.Set([]byte("b"), []byte("1234"), 0))
+	require.NoError(t, r.Set([]byte("c"), []byte("1234"), 0))
+	assert.Equal(t, int64(2), r.EntryCount())
+	assert.LessOrEqual(t, r.bytes, int64(10))
+
+	// growing a resident entry evicts others instead of exceeding the bound
+	require.NoError(t, r.Set([]byte("c"), []byte("123456789"), 0))
+	assert.Equal(t, int64(1), r.EntryCount())
+
+	assert.ErrorIs(t, r.Set([]byte("d"), []byte("12345678901"), 0), ErrTooLarge)
+}
+
+func TestLRUExpires(t *testing.T) {
+	r, now := newRepo(Options{MaxEntries: 10})
+	require.NoError(t, r.Set([]byte("a"), []byte("1"), 5))
+	require.NoError(t, r.Set([]byte("b"), []byte("2"), 0))
+
+	ttl, err := r.TTL([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, ttl)
+	ttl, err = r.TTL([]byte("b"))
+	require.NoError(t, err)
+	assert.Zero(t, ttl)
+
+	*now = now.Add(5 * time.Second)
+	_, err = r.Get([]byte("a"))
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	_, err = r.TTL([]byte("a"))
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	assert.Equal(t, int64(1), r.Stats().Expired)
+}
+
+func TestTinyLFUKeepsHotEntriesDuringScans(t *testing.T) {
+	r, _ := newRepo(Options{MaxEntries: 10, Policy: TinyLFU})
+	for i := 0; i < 10; i++ {
+		key := []byte("hot" + strconv.Itoa(i))
+		require.NoError(t, r.Set(key, []byte("v"), 0))
+		for j := 0; j < 5; j++ {
+			_, err := r.Get(key)
+			require.NoError(t, err)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		err := r.Set([]byte("scan"+strconv.Itoa(i)), []byte("v"), 0)
+		if err != nil {
+			require.ErrorIs(t, err, ErrRejected)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		_, err := r.Get([]byte("hot" + strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	plain, _ := newRepo(Options{MaxEntries: 10, Policy: LRU})
+	for i := 0; i < 10; i++ {
+		require.NoError(t, plain.Set([]byte("hot"+strconv.Itoa(i)), []byte("v"), 0))
+	}
+	for i := 0; i < 100; i++ {
+		require.NoError(t, plain.Set([]byte("scan"+strconv.Itoa(i)), []byte("v"), 0))
+	}
+	_, err := plain.Get([]byte("hot0"))
+	assert.ErrorIs(t, err, cache.ErrNotFound, "plain LRU is flushed by the scan")
+}
+
+func TestTinyLFURejectsNewKeyWhenFull(t *testing.T) {
+	r, _ := newRepo(Options{MaxEntries: 2, Policy: TinyLFU})
+	for _, key := range []string{"a", "b"} {
+		require.NoError(t, r.Set([]byte(key), []byte("v"), 0))
+		_, err := r.Get([]byte(key))
+		require.NoError(t, err)
+	}
+
+	// a rejected write is reported, the caller must not assume it is stored
+	assert.ErrorIs(t, r.Set([]byte("c"), []byte("v"), 0), ErrRejected)
+	_, err := r.Get([]byte("c"))
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	assert.Equal(t, int64(2), r.EntryCount())
+	assert.Zero(t, r.Stats().Evictions)
+
+	// resident keys are always replaced
+	require.NoError(t, r.Set([]byte("a"), []byte("w"), 0))
+
+	plain, _ := newRepo(Options{MaxEntries: 2, Policy: LRU})
+	require.NoError(t, plain.Set([]byte("a"), []byte("v"), 0))
+	require.NoError(t, plain.Set([]byte("b"), []byte("v"), 0))
+	require.NoError(t, plain.Set([]byte("c"), []byte("v"), 0))
+	got, err := plain.Get([]byte("c"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v"), got)
+}
+
+func TestLRUPrefixClearAndIterator(t *testing.T) {
+	r, _ := newRepo(Options{MaxEntries: 10})
+	for _, key := range []string{"product:1", "product:2", "category:1"} {
+		require.NoError(t, r.Set([]byte(key), []byte(key), 0))
+	}
+
+	it := r.GetIterator()
+	assert.Equal(t, 2, r.DelPrefix([]byte("product:")))
+	seen := 0
+	for entry := it.Next(); entry != nil; entry = it.Next() {
+		seen++
+	}
+	assert.Equal(t, 3, seen, "the iterator is a snapshot")
+
+	r.Clear()
+	assert.Equal(t, int64(0), r.EntryCount())
+	assert.Zero(t, r.bytes)
+}
@@ -0,0 +1,75 @@
+package lru
+
+import "hash/maphash"
+
+// sketchDepth rows of counters are kept, an estimate is the smallest of them.
+const sketchDepth = 4
+
+// maxCount saturates the counters, frequencies above it are all "hot".
+const maxCount = 15
+
+// sketch is a count-min sketch estimating how often keys were accessed.
+// Counters are halved every resetAfter additions, so the estimate follows
+// the recent popularity of a key rather than its whole history.
+type sketch struct {
+	seed       maphash.Seed
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	resetAfter int
+}
+
+// newSketch sizes the rows to the next power of two of at least width.
+func newSketch(width int) *sketch {
+	size := 1024
+	for size < width {
+		size *= 2
+	}
+	s := &sketch{seed: maphash.MakeSeed(), mask: uint64(size - 1), resetAfter: 10 * size}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, size)
+	}
+	return s
+}
+
+// indexes derives one counter per row from a single hash.
+func (s *sketch) indexes(key string) [sketchDepth]uint64 {
+	h := maphash.String(s.seed, key)
+	low, high := h&0xffffffff, h>>32
+	var idx [sketchDepth]uint64
+	for i := range idx {
+		idx[i] = (low + uint64(i)*high) & s.mask
+	}
+	return idx
+}
+
+func (s *sketch) increment(key string) {
+	for i, idx := range s.indexes(key) {
+		if s.rows[i][idx] < maxCount {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.resetAfter {
+		s.halve()
+	}
+}
+
+func (s *sketch) estimate(key string) uint8 {
+	estimate := uint8(maxCount)
+	for i, idx := range s.indexes(key) {
+		if s.rows[i][idx] < estimate {
+			estimate = s.rows[i][idx]
+		}
+	}
+	return estimate
+}
+
+func (s *sketch) halve() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mod/pkg/metrics"
+)
+
+type collector struct {
+	repository Repository
+	entries    *prometheus.Desc
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	evictions  *prometheus.Desc
+	expired    *prometheus.Desc
+}
+
+// NewCollector exports the Stats of repository labelled with name, the entry
+// count as a gauge and the rest as counters. The values are read from the
+// repository at scrape time.
+func NewCollector(name string, repository Repository) prometheus.Collector {
+	labels := prometheus.Labels{"cache": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "cache", metric), help, nil, labels)
+	}
+	return &collector{
+		repository: repository,
+		entries:    desc("entries", "Number of entries currently in the cache."),
+		hits:       desc("hits_total", "Number of lookups that found their key."),
+		misses:     desc("misses_total", "Number of lookups that did not find their key."),
+		evictions:  desc("evictions_total", "Number of live entries dropped to make room."),
+		expired:    desc("expired_total", "Number of entries dropped because they expired."),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expired
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.repository.Stats()
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(stats.Expired))
+}
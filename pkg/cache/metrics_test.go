@@ -0,0 +1,42 @@
+package cache_test
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/cache/lru"
+	"strings"
+	"testing"
+)
+
+func TestCollectorTypes(t *testing.T) {
+	r := lru.NewCacheRepo(lru.Options{MaxEntries: 1})
+	require.NoError(t, r.Set([]byte("a"), []byte("1"), 0))
+	require.NoError(t, r.Set([]byte("b"), []byte("2"), 0))
+	_, err := r.Get([]byte("b"))
+	require.NoError(t, err)
+	_, err = r.Get([]byte("a"))
+	require.ErrorIs(t, err, cache.ErrNotFound)
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(cache.NewCollector("test", r)))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	types := make(map[string]string, len(families))
+	for _, family := range families {
+		types[family.GetName()[strings.Index(family.GetName(), "cache_"):]] = family.GetType().String()
+	}
+	assert.Equal(t, map[string]string{
+		"cache_entries":         "GAUGE",
+		"cache_hits_total":      "COUNTER",
+		"cache_misses_total":    "COUNTER",
+		"cache_evictions_total": "COUNTER",
+		"cache_expired_total":   "COUNTER",
+	}, types)
+
+	problems, err := testutil.CollectAndLint(cache.NewCollector("test", r))
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"bytes"
+	"sync/atomic"
+	"time"
+)
+
+type namespace struct {
+	parent Repository
+	prefix []byte
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Namespace returns a view of r holding the keys prefixed with name and a
+// colon, several views share the memory of r without seeing each other's
+// keys. Hits and misses are counted per view, evictions, expirations and
+// the access time in Stats are those of r.
+func Namespace(r Repository, name string) Repository {
+	return &namespace{parent: r, prefix: []byte(name + ":")}
+}
+
+func (n *namespace) key(key []byte) []byte {
+	full := make([]byte, 0, len(n.prefix)+len(key))
+	return append(append(full, n.prefix...), key...)
+}
+
+func (n *namespace) count(found bool) {
+	if found {
+		n.hits.Add(1)
+	} else {
+		n.misses.Add(1)
+	}
+}
+
+func (n *namespace) GetIterator() Iterator {
+	var entries []Entry
+	it := n.parent.GetIterator()
+	for entry := it.Next(); entry != nil; entry = it.Next() {
+		if bytes.HasPrefix(entry.Key, n.prefix) {
+			entries = append(entries, Entry{Key: entry.Key[len(n.prefix):], Value: entry.Value})
+		}
+	}
+	return NewSliceIterator(entries)
+}
+
+func (n *namespace) Get(key []byte) ([]byte, error) {
+	got, err := n.parent.Get(n.key(key))
+	n.count(err == nil)
+	return got, err
+}
+
+func (n *namespace) GetMulti(keys [][]byte) map[string][]byte {
+	full := make([][]byte, len(keys))
+	for i, key := range keys {
+		full[i] = n.key(key)
+	}
+	found := make(map[string][]byte)
+	for key, value := range n.parent.GetMulti(full) {
+		found[key[len(n.prefix):]] = value
+	}
+	n.hits.Add(int64(len(found)))
+	n.misses.Add(int64(len(keys) - len(found)))
+	return found
+}
+
+func (n *namespace) Set(key []byte, val []byte, expireIn int) error {
+	return n.parent.Set(n.key(key), val, expireIn)
+}
+
+func (n *namespace) SetMulti(entries []Entry, expireIn int) error {
+	full := make([]Entry, len(entries))
+	for i, entry := range entries {
+		full[i] = Entry{Key: n.key(entry.Key), Value: entry.Value}
+	}
+	return n.parent.SetMulti(full, expireIn)
+}
+
+func (n *namespace) TTL(key []byte) (time.Duration, error) {
+	return n.parent.TTL(n.key(key))
+}
+
+func (n *namespace) Del(key []byte) (affected bool) {
+	return n.parent.Del(n.key(key))
+}
+
+func (n *namespace) DelPrefix(prefix []byte) int {
+	return n.parent.DelPrefix(n.key(prefix))
+}
+
+func (n *namespace) Clear() {
+	n.parent.DelPrefix(n.prefix)
+}
+
+// EntryCount walks the entries of the parent, it is as costly as iterating.
+func (n *namespace) EntryCount() (entryCount int64) {
+	it := n.GetIterator()
+	for entry := it.Next(); entry != nil; entry = it.Next() {
+		entryCount++
+	}
+	return entryCount
+}
+
+func (n *namespace) HitCount() int64 {
+	return n.hits.Load()
+}
+
+func (n *namespace) MissCount() int64 {
+	return n.misses.Load()
+}
+
+func (n *namespace) Stats() Stats {
+	stats := n.parent.Stats()
+	stats.Entries = n.EntryCount()
+	stats.Hits, stats.Misses = n.HitCount(), n.MissCount()
+	stats.HitRate = hitRate(stats.Hits, stats.Misses)
+	return stats
+}
+
+// Close drops the entries of the namespace, the parent stays open.
+func (n *namespace) Close() error {
+	n.Clear()
+	return nil
+}
@@ -0,0 +1,46 @@
+package cache_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/cache/freecache"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	shared := freecache.NewCacheRepo(1024 * 1024)
+	products := cache.Namespace(shared, "products")
+	categories := cache.Namespace(shared, "categories")
+
+	require.NoError(t, products.Set([]byte("1"), []byte("lamp"), 0))
+	require.NoError(t, categories.Set([]byte("1"), []byte("home"), 0))
+	require.NoError(t, products.SetMulti([]cache.Entry{{Key: []byte("2"), Value: []byte("desk")}}, 0))
+
+	got, err := products.Get([]byte("1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("lamp"), got)
+	got, err = shared.Get([]byte("categories:1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("home"), got)
+
+	found := products.GetMulti([][]byte{[]byte("1"), []byte("2"), []byte("3")})
+	assert.Equal(t, map[string][]byte{"1": []byte("lamp"), "2": []byte("desk")}, found)
+
+	var keys []string
+	it := products.GetIterator()
+	for entry := it.Next(); entry != nil; entry = it.Next() {
+		keys = append(keys, string(entry.Key))
+	}
+	assert.ElementsMatch(t, []string{"1", "2"}, keys)
+
+	stats := products.Stats()
+	assert.Equal(t, int64(2), stats.Entries)
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+
+	products.Clear()
+	assert.Equal(t, int64(0), products.EntryCount())
+	assert.Equal(t, int64(1), categories.EntryCount())
+	assert.Equal(t, int64(1), shared.EntryCount())
+}
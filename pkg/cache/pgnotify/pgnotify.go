@@ -0,0 +1,147 @@
+// Package pgnotify carries cache invalidations over Postgres LISTEN/NOTIFY.
+// Notifications sent inside a transaction are delivered when it commits, so
+// other instances never evict a key before the change is visible to them.
+package pgnotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/logging"
+	"go.mod/pkg/utils"
+	"time"
+)
+
+// Channel is the notification channel invalidations are sent on.
+const Channel = "cache_invalidation"
+
+// maxPayload keeps a notification below the 8000 bytes NOTIFY accepts.
+const maxPayload = 7900
+
+var _ cache.Bus = &bus{}
+
+type bus struct {
+	client  postgresql.Client
+	pool    *pgxpool.Pool
+	backoff utils.Backoff
+	logger  *logging.Logger
+}
+
+// NewBus publishes through client, so invalidations join the transaction of
+// the write that caused them, and listens on a connection taken from pool.
+func NewBus(client postgresql.Client, pool *pgxpool.Pool, logger *logging.Logger) cache.Bus {
+	return &bus{
+		client:  client,
+		pool:    pool,
+		backoff: utils.Backoff{Initial: 500 * time.Millisecond, Max: 30 * time.Second},
+		logger:  logger,
+	}
+}
+
+func (b *bus) Publish(ctx context.Context, keys ...string) error {
+	batches, err := batch(keys)
+	if err != nil {
+		return err
+	}
+	ctx = postgresql.Named(ctx, "pgnotify", "Publish")
+	q := `SELECT pg_notify($1, $2)`
+	for _, payload := range batches {
+		logging.FromContext(ctx).Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+		if _, err := b.client.Exec(ctx, q, Channel, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe listens until ctx is done and reconnects with backoff when the
+// connection is lost. Notifications sent meanwhile are lost too, so handler
+// is called with nil keys once listening again.
+func (b *bus) Subscribe(ctx context.Context, handler func(keys []string)) error {
+	connected := false
+	for attempt := 0; ; attempt++ {
+		err := b.listen(ctx, handler, func() {
+			if connected {
+				handler(nil)
+			}
+			connected = true
+			attempt = 0
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		delay := b.backoff.Delay(attempt)
+		b.logger.Warnf("cache invalidation listener failed, retrying in %s: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// listen holds a connection outside the pool for as long as it listens,
+// ready is called once notifications are received.
+func (b *bus) listen(ctx context.Context, handler func(keys []string), ready func()) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := pooled.Hijack()
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn.Close(closeCtx)
+	}()
+
+	q := "LISTEN " + pgx.Identifier{Channel}.Sanitize()
+	b.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatQuery(q)))
+	if _, err := conn.Exec(ctx, q); err != nil {
+		return err
+	}
+	ready()
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		keys := []string{}
+		if err := json.Unmarshal([]byte(notification.Payload), &keys); err != nil {
+			b.logger.Warnf("malformed cache invalidation %q: %v", notification.Payload, err)
+			continue
+		}
+		b.logger.Tracef("cache invalidation: %v", keys)
+		handler(keys)
+	}
+}
+
+// batch encodes keys as JSON arrays that each fit in one notification.
+func batch(keys []string) ([]string, error) {
+	var batches []string
+	var current []string
+	size := 2
+	flush := func() {
+		if len(current) > 0 {
+			payload, _ := json.Marshal(current)
+			batches = append(batches, string(payload))
+		}
+		current, size = nil, 2
+	}
+	for _, key := range keys {
+		encoded, _ := json.Marshal(key)
+		if len(encoded)+3 > maxPayload {
+			return nil, fmt.Errorf("cache key of %d bytes is too long to publish", len(key))
+		}
+		if size+len(encoded)+1 > maxPayload {
+			flush()
+		}
+		current = append(current, key)
+		size += len(encoded) + 1
+	}
+	flush()
+	return batches, nil
+}
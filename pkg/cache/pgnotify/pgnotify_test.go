@@ -0,0 +1,39 @@
+package pgnotify
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	batches, err := batch([]string{"product:1", "category:all"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`["product:1","category:all"]`}, batches)
+
+	var keys []string
+	long := strings.Repeat("k", 1000)
+	for i := 0; i < 20; i++ {
+		keys = append(keys, long)
+	}
+	batches, err = batch(keys)
+	require.NoError(t, err)
+	require.Greater(t, len(batches), 1)
+	var decoded []string
+	for _, payload := range batches {
+		assert.LessOrEqual(t, len(payload), maxPayload)
+		var part []string
+		require.NoError(t, json.Unmarshal([]byte(payload), &part))
+		decoded = append(decoded, part...)
+	}
+	assert.Equal(t, keys, decoded)
+
+	_, err = batch([]string{strings.Repeat("k", maxPayload)})
+	assert.Error(t, err)
+
+	batches, err = batch(nil)
+	require.NoError(t, err)
+	assert.Empty(t, batches)
+}
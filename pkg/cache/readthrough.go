@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"sync"
+	"time"
+)
+
+// Entries start with a tag telling a stored value from a remembered miss,
+// followed by the epoch they were stored in.
+const (
+	tagValue    byte = 'v'
+	tagNotFound byte = 'n'
+	headerSize       = 9
+
+	defaultLoadTimeout = 10 * time.Second
+)
+
+type Options struct {
+	// TTL is how long loaded values are kept, it is rounded up to seconds.
+	TTL time.Duration
+	// NegativeTTL is how long a load failing with NotFound is remembered,
+	// 0 disables negative caching.
+	NegativeTTL time.Duration
+	// NotFound is returned for remembered misses. Load errors matching it
+	// with errors.Is are the ones remembered.
+	NotFound error
+	// LoadTimeout bounds a load, 10s when 0. Loads are shared by every caller
+	// waiting for the key, so they do not stop when one of the callers leaves.
+	LoadTimeout time.Duration
+}
+
+// ReadThrough fills a Repository from a loader on a miss. Concurrent misses
+// of one key share a single load, every caller decodes its own copy.
+type ReadThrough struct {
+	repository Repository
+	options    Options
+	group      singleflight.Group
+	// generation changes on every invalidation, a load that started before
+	// one does not store its possibly stale result. Entries of an older
+	// epoch are ignored, Reset drops them all at once.
+	mu         sync.Mutex
+	generation uint64
+	epoch      uint64
+}
+
+func NewReadThrough(repository Repository, options Options) *ReadThrough {
+	if options.LoadTimeout <= 0 {
+		options.LoadTimeout = defaultLoadTimeout
+	}
+	return &ReadThrough{repository: repository, options: options}
+}
+
+// detached carries the values of a context, such as its logger and span, but
+// neither its deadline nor its cancellation.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+
+// Fetch decodes the value cached under key into v. On a miss it calls load
+// and caches the JSON encoding of the result. The load runs detached from the
+// caller that started it, bounded by LoadTimeout, and every caller waiting for
+// it gives up on its own context only.
+func (r *ReadThrough) Fetch(ctx context.Context, key string, v interface{}, load func(ctx context.Context) (interface{}, error)) error {
+	r.mu.Lock()
+	generation, epoch := r.generation, r.epoch
+	r.mu.Unlock()
+	if entry, err := r.repository.Get([]byte(key)); err == nil && len(entry) >= headerSize && binary.BigEndian.Uint64(entry[1:headerSize]) == epoch {
+		if entry[0] == tagNotFound {
+			return r.options.NotFound
+		}
+		return json.Unmarshal(entry[headerSize:], v)
+	}
+
+	results := r.group.DoChan(key, func() (data interface{}, err error) {
+		// DoChan would re-panic where nothing recovers, the callers get an
+		// error instead
+		defer func() {
+			if p := recover(); p != nil {
+				data, err = nil, fmt.Errorf("cache: load of %s panicked: %v", key, p)
+			}
+		}()
+		ctx, cancel := context.WithTimeout(detached{ctx}, r.options.LoadTimeout)
+		defer cancel()
+		value, err := load(ctx)
+		if err != nil {
+			if r.options.NegativeTTL > 0 && r.options.NotFound != nil && errors.Is(err, r.options.NotFound) {
+				r.store(generation, key, tagNotFound, nil, r.options.NegativeTTL)
+			}
+			return nil, err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		r.store(generation, key, tagValue, encoded, r.options.TTL)
+		return encoded, nil
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return result.Err
+		}
+		return json.Unmarshal(result.Val.([]byte), v)
+	}
+}
+
+// Invalidate drops the entries of keys, values and remembered misses alike.
+func (r *ReadThrough) Invalidate(keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	for _, key := range keys {
+		r.group.Forget(key)
+		r.repository.Del([]byte(key))
+	}
+}
+
+// Reset drops every entry, for when invalidations may have been missed.
+func (r *ReadThrough) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	r.epoch++
+}
+
+// Evict applies keys received from a Bus, nil keys reset the cache.
+func (r *ReadThrough) Evict(keys []string) {
+	if keys == nil {
+		r.Reset()
+		return
+	}
+	r.Invalidate(keys...)
+}
+
+// store skips entries loaded before an invalidation. A failed Set only
+// costs a later miss, so it is not reported.
+func (r *ReadThrough) store(generation uint64, key string, tag byte, data []byte, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.generation != generation {
+		return
+	}
+	entry := make([]byte, headerSize, headerSize+len(data))
+	entry[0] = tag
+	binary.BigEndian.PutUint64(entry[1:headerSize], r.epoch)
+	entry = append(entry, data...)
+	_ = r.repository.Set([]byte(key), entry, expireIn(ttl))
+}
@@ -0,0 +1,188 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/cache/freecache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errNotFound = errors.New("not found")
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func newReadThrough() *cache.ReadThrough {
+	return cache.NewReadThrough(freecache.NewCacheRepo(1024*1024), cache.Options{
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+		NotFound:    errNotFound,
+	})
+}
+
+func TestReadThroughCachesValues(t *testing.T) {
+	c := newReadThrough()
+	var loads int
+	load := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return item{Name: "lamp"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		var got item
+		require.NoError(t, c.Fetch(context.Background(), "k", &got, load))
+		assert.Equal(t, "lamp", got.Name)
+	}
+	assert.Equal(t, 1, loads)
+
+	c.Invalidate("k")
+	var got item
+	require.NoError(t, c.Fetch(context.Background(), "k", &got, load))
+	assert.Equal(t, 2, loads)
+}
+
+func TestReadThroughRemembersNotFound(t *testing.T) {
+	c := newReadThrough()
+	var loads int
+	load := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return nil, errNotFound
+	}
+
+	var got item
+	assert.ErrorIs(t, c.Fetch(context.Background(), "k", &got, load), errNotFound)
+	assert.ErrorIs(t, c.Fetch(context.Background(), "k", &got, load), errNotFound)
+	assert.Equal(t, 1, loads)
+
+	other := errors.New("connection refused")
+	failing := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return nil, other
+	}
+	assert.ErrorIs(t, c.Fetch(context.Background(), "other", &got, failing), other)
+	assert.ErrorIs(t, c.Fetch(context.Background(), "other", &got, failing), other)
+	assert.Equal(t, 3, loads, "only not found results are remembered")
+}
+
+func TestReadThroughCollapsesConcurrentMisses(t *testing.T) {
+	c := newReadThrough()
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (interface{}, error) {
+		loads.Add(1)
+		<-release
+		return item{Name: "lamp"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got item
+			assert.NoError(t, c.Fetch(context.Background(), "k", &got, load))
+			assert.Equal(t, "lamp", got.Name)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), loads.Load())
+}
+
+func TestReadThroughDropsLoadsRacingInvalidation(t *testing.T) {
+	c := newReadThrough()
+	var loads int
+	load := func(ctx context.Context) (interface{}, error) {
+		loads++
+		if loads == 1 {
+			c.Invalidate("k")
+		}
+		return item{Name: "lamp"}, nil
+	}
+
+	var got item
+	require.NoError(t, c.Fetch(context.Background(), "k", &got, load))
+	require.NoError(t, c.Fetch(context.Background(), "k", &got, load))
+	assert.Equal(t, 2, loads, "a value loaded before an invalidation is not stored")
+}
+
+func TestReadThroughEvict(t *testing.T) {
+	c := newReadThrough()
+	var loads int
+	load := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return item{Name: "lamp"}, nil
+	}
+	var got item
+	require.NoError(t, c.Fetch(context.Background(), "a", &got, load))
+	require.NoError(t, c.Fetch(context.Background(), "b", &got, load))
+
+	c.Evict([]string{"a"})
+	require.NoError(t, c.Fetch(context.Background(), "a", &got, load))
+	require.NoError(t, c.Fetch(context.Background(), "b", &got, load))
+	assert.Equal(t, 3, loads, "only the published key is evicted")
+
+	c.Evict(nil)
+	require.NoError(t, c.Fetch(context.Background(), "a", &got, load))
+	require.NoError(t, c.Fetch(context.Background(), "b", &got, load))
+	assert.Equal(t, 5, loads, "nil keys drop every entry")
+}
+
+func TestReadThroughLoadOutlivesCaller(t *testing.T) {
+	c := newReadThrough()
+	release := make(chan struct{})
+	var loadErr error
+	load := func(ctx context.Context) (interface{}, error) {
+		<-release
+		loadErr = ctx.Err()
+		return item{Name: "lamp"}, nil
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		var got item
+		firstDone <- c.Fetch(first, "k", &got, load)
+	}()
+	secondDone := make(chan error)
+	var second item
+	go func() {
+		// waits for the load the first caller started
+		time.Sleep(10 * time.Millisecond)
+		secondDone <- c.Fetch(context.Background(), "k", &second, load)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-firstDone, context.Canceled, "a caller gives up on its own context")
+	close(release)
+	require.NoError(t, <-secondDone)
+	assert.Equal(t, "lamp", second.Name)
+	assert.NoError(t, loadErr, "the shared load is not cancelled with the first caller")
+}
+
+func TestReadThroughLoadTimeout(t *testing.T) {
+	c := cache.NewReadThrough(freecache.NewCacheRepo(1024*1024), cache.Options{TTL: time.Minute, LoadTimeout: 10 * time.Millisecond})
+	var got item
+	err := c.Fetch(context.Background(), "k", &got, func(ctx context.Context) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(10*time.Millisecond), deadline, 10*time.Millisecond)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = c.Fetch(context.Background(), "p", &got, func(ctx context.Context) (interface{}, error) {
+		panic("broken loader")
+	})
+	assert.ErrorContains(t, err, "broken loader")
+}
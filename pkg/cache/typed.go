@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned for keys that have no entry.
+var ErrNotFound = errors.New("cache: entry not found")
+
+// Key is the set of types a TypedCache can be keyed by, each value formats
+// to a distinct string.
+type Key interface {
+	~string | ~int | ~int32 | ~int64 | ~uint | ~uint32 | ~uint64
+}
+
+// TypedCache stores values of V under keys of K in a Repository, encoding
+// them with a Codec so callers never handle bytes.
+type TypedCache[K Key, V any] struct {
+	repository Repository
+	codec      Codec
+}
+
+func NewTypedCache[K Key, V any](repository Repository, codec Codec) *TypedCache[K, V] {
+	return &TypedCache[K, V]{repository: repository, codec: codec}
+}
+
+func (c *TypedCache[K, V]) key(key K) []byte {
+	return []byte(fmt.Sprint(key))
+}
+
+// expireIn rounds ttl up to the seconds Repository counts in, 0 keeps the
+// entry until it is evicted.
+func expireIn(ttl time.Duration) int {
+	if ttl <= 0 {
+		return 0
+	}
+	return int((ttl + time.Second - 1) / time.Second)
+}
+
+// Get returns the value of key, or an error matching ErrNotFound.
+func (c *TypedCache[K, V]) Get(key K) (value V, err error) {
+	data, err := c.repository.Get(c.key(key))
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			err = fmt.Errorf("%w: %v", ErrNotFound, err)
+		}
+		return value, err
+	}
+	err = c.codec.Unmarshal(data, &value)
+	return value, err
+}
+
+// GetMulti returns the values of the keys that were found, an entry that
+// can not be decoded fails the whole call.
+func (c *TypedCache[K, V]) GetMulti(keys []K) (map[K]V, error) {
+	byName := make(map[string]K, len(keys))
+	raw := make([][]byte, len(keys))
+	for i, key := range keys {
+		raw[i] = c.key(key)
+		byName[string(raw[i])] = key
+	}
+	values := make(map[K]V)
+	for name, data := range c.repository.GetMulti(raw) {
+		var value V
+		if err := c.codec.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		values[byName[name]] = value
+	}
+	return values, nil
+}
+
+func (c *TypedCache[K, V]) Set(key K, value V, ttl time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.repository.Set(c.key(key), data, expireIn(ttl))
+}
+
+func (c *TypedCache[K, V]) SetMulti(values map[K]V, ttl time.Duration) error {
+	entries := make([]Entry, 0, len(values))
+	for key, value := range values {
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{Key: c.key(key), Value: data})
+	}
+	return c.repository.SetMulti(entries, expireIn(ttl))
+}
+
+func (c *TypedCache[K, V]) Del(key K) (affected bool) {
+	return c.repository.Del(c.key(key))
+}
+
+// TTL returns the time left until the entry of key expires, 0 when it does not.
+func (c *TypedCache[K, V]) TTL(key K) (time.Duration, error) {
+	return c.repository.TTL(c.key(key))
+}
@@ -0,0 +1,47 @@
+package cache_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/pkg/cache"
+	"go.mod/pkg/cache/lru"
+	"testing"
+	"time"
+)
+
+type account struct {
+	ID       int            `json:"id"`
+	Username string         `json:"username"`
+	Tags     map[string]int `json:"tags"`
+}
+
+func TestTypedCache(t *testing.T) {
+	codecs := map[string]cache.Codec{"json": cache.JSON, "gob": cache.Gob, "msgpack": cache.MsgPack}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			accounts := cache.NewTypedCache[int, account](lru.NewCacheRepo(lru.Options{MaxEntries: 10}), codec)
+			alice := account{ID: 1, Username: "alice", Tags: map[string]int{"admin": 1}}
+
+			require.NoError(t, accounts.Set(1, alice, time.Minute))
+			got, err := accounts.Get(1)
+			require.NoError(t, err)
+			assert.Equal(t, alice, got)
+
+			ttl, err := accounts.TTL(1)
+			require.NoError(t, err)
+			assert.Equal(t, time.Minute, ttl.Round(time.Second))
+
+			_, err = accounts.Get(2)
+			assert.ErrorIs(t, err, cache.ErrNotFound)
+
+			bob := account{ID: 2, Username: "bob", Tags: map[string]int{}}
+			require.NoError(t, accounts.SetMulti(map[int]account{2: bob}, 0))
+			found, err := accounts.GetMulti([]int{1, 2, 3})
+			require.NoError(t, err)
+			assert.Equal(t, map[int]account{1: alice, 2: bob}, found)
+
+			assert.True(t, accounts.Del(1))
+			assert.False(t, accounts.Del(1))
+		})
+	}
+}
@@ -5,20 +5,15 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"net"
 )
 
+// NewClient connects to the server and returns database. The credentials are
+// passed apart from the URI, so they need no escaping.
 func NewClient(ctx context.Context,
 	host, port, username, password, database, authDB string) (*mongo.Database, error) {
-	var mongoDBUrl string
-	var isAuth bool
-	if username == "" && password == "" {
-		mongoDBUrl = fmt.Sprintf("mongodb://%s:%s", host, port)
-	} else {
-		mongoDBUrl = fmt.Sprintf("mongodb://%s:%s@%s:%s", username, password, host, port)
-		isAuth = true
-	}
-	clientOptions := options.Client().ApplyURI(mongoDBUrl)
-	if isAuth {
+	clientOptions := options.Client().ApplyURI("mongodb://" + net.JoinHostPort(host, port))
+	if username != "" || password != "" {
 		if authDB == "" {
 			authDB = database
 		}
@@ -35,6 +30,7 @@ func NewClient(ctx context.Context,
 	}
 
 	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
 		return nil, fmt.Errorf("failed to ping to mongodb due to error: %v", err)
 	}
 	return client.Database(database), nil
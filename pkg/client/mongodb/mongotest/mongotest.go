@@ -0,0 +1,36 @@
+// Package mongotest connects tests to the MongoDB server named by
+// MONGODB_TEST_URI and skips them when it is not set.
+package mongotest
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"testing"
+	"time"
+)
+
+const uriEnv = "MONGODB_TEST_URI"
+
+// Database returns an empty database of its own to t, dropped when t ends.
+func Database(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv(uriEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", uriEnv)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to mongodb: %v", err)
+	}
+	database := client.Database(fmt.Sprintf("go_blog_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return database
+}
@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CountersCollection holds one document per sequence with its last value.
+const CountersCollection = "counters"
+
+// NextID returns the next value of the sequence name, starting at 1. It gives
+// documents the integer ids the Postgres SERIAL columns give rows, so both
+// backends share the same models.
+func NextID(ctx context.Context, db *mongo.Database, name string) (int, error) {
+	var counter struct {
+		Value int `bson:"value"`
+	}
+	err := db.Collection(CountersCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": name},
+		bson.M{"$inc": bson.M{"value": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Value, err
+}
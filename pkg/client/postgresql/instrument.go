@@ -0,0 +1,100 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"go.mod/pkg/tracing"
+	"go.mod/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+	"time"
+)
+
+type queryKey struct{}
+
+// queryName labels the queries of a repository method.
+type queryName struct {
+	repository string
+	method     string
+}
+
+var unnamed = queryName{repository: "unknown", method: "unknown"}
+
+// Named returns a context whose queries are labelled, in spans and in the
+// query duration metric, as method of repository.
+func Named(ctx context.Context, repository, method string) context.Context {
+	return context.WithValue(ctx, queryKey{}, queryName{repository: repository, method: method})
+}
+
+func nameOf(ctx context.Context) queryName {
+	if name, ok := ctx.Value(queryKey{}).(queryName); ok {
+		return name
+	}
+	return unnamed
+}
+
+// startQuery attributes a query to the repository method named in ctx. It
+// starts a client span carrying the statement and the pool it goes to, the
+// returned function ends it and records the query duration.
+func startQuery(ctx context.Context, sql, target string) (context.Context, func(err error)) {
+	start := time.Now()
+	name := nameOf(ctx)
+	ctx, span := tracing.Start(ctx, name.repository+"."+name.method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatement(utils.FormatQuery(sql)),
+			attribute.Bool("db.in_transaction", InTx(ctx)),
+			attribute.String("db.target", target),
+		),
+	)
+	return ctx, func(err error) {
+		queryDuration.WithLabelValues(name.repository, name.method, target).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}
+}
+
+// instrumentedRow ends the query when it is scanned. No rows is an expected
+// outcome of a lookup and does not mark the span as failed.
+type instrumentedRow struct {
+	pgx.Row
+	end func(err error)
+}
+
+func (r *instrumentedRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.end(nil)
+	} else {
+		r.end(err)
+	}
+	return err
+}
+
+// instrumentedRows ends the query once the rows are exhausted or closed,
+// whichever comes first.
+type instrumentedRows struct {
+	pgx.Rows
+	end  func(err error)
+	once sync.Once
+}
+
+func (r *instrumentedRows) finish() {
+	r.once.Do(func() { r.end(r.Rows.Err()) })
+}
+
+func (r *instrumentedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
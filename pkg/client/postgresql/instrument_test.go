@@ -0,0 +1,102 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, unnamed, nameOf(ctx))
+
+	ctx = Named(ctx, "product", "FindAll")
+	assert.Equal(t, queryName{repository: "product", method: "FindAll"}, nameOf(WithPrimary(ctx)))
+	assert.Equal(t, queryName{repository: "pgnotify", method: "Publish"}, nameOf(Named(ctx, "pgnotify", "Publish")), "the innermost name wins")
+}
+
+type row struct {
+	err error
+}
+
+func (r row) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+// rows yields n rows, then reports err.
+type rows struct {
+	pgx.Rows
+	n      int
+	err    error
+	closed bool
+}
+
+func (r *rows) Next() bool {
+	if r.n == 0 {
+		return false
+	}
+	r.n--
+	return true
+}
+
+func (r *rows) Err() error {
+	return r.err
+}
+
+func (r *rows) Close() {
+	r.closed = true
+}
+
+// recorder counts how often a query ended and keeps the last error.
+type recorder struct {
+	ends int
+	err  error
+}
+
+func (r *recorder) end(err error) {
+	r.ends++
+	r.err = err
+}
+
+func TestInstrumentedRowEndsOnScan(t *testing.T) {
+	errBroken := errors.New("broken")
+	tests := []struct {
+		scanErr, want error
+	}{
+		{nil, nil},
+		{pgx.ErrNoRows, nil},
+		{errBroken, errBroken},
+	}
+	for _, tt := range tests {
+		var rec recorder
+		r := &instrumentedRow{Row: row{err: tt.scanErr}, end: rec.end}
+		assert.Zero(t, rec.ends, "the query is still running before Scan")
+		assert.ErrorIs(t, r.Scan(), tt.scanErr)
+		assert.Equal(t, 1, rec.ends)
+		assert.Equal(t, tt.want, rec.err)
+	}
+}
+
+func TestInstrumentedRowsEndOnce(t *testing.T) {
+	var rec recorder
+	inner := &rows{n: 2}
+	r := &instrumentedRows{Rows: inner, end: rec.end}
+	assert.True(t, r.Next())
+	assert.True(t, r.Next())
+	assert.Zero(t, rec.ends, "the query is still running while rows are read")
+	assert.False(t, r.Next())
+	assert.Equal(t, 1, rec.ends)
+	r.Close()
+	assert.True(t, inner.closed)
+	assert.Equal(t, 1, rec.ends)
+
+	rec = recorder{}
+	errBroken := errors.New("broken")
+	r = &instrumentedRows{Rows: &rows{n: 5, err: errBroken}, end: rec.end}
+	assert.True(t, r.Next())
+	r.Close()
+	assert.Equal(t, 1, rec.ends, "closing early ends the query")
+	assert.ErrorIs(t, rec.err, errBroken)
+}
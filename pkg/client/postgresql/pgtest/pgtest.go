@@ -0,0 +1,63 @@
+// Package pgtest connects tests to the Postgres database named by
+// POSTGRES_TEST_DSN and skips them when it is not set. The database is
+// migrated and emptied, it must not hold anything worth keeping.
+package pgtest
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.mod/migrations"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/logging"
+	"go.mod/pkg/migrate"
+	"os"
+	"testing"
+	"time"
+)
+
+const dsnEnv = "POSTGRES_TEST_DSN"
+
+// tables are emptied before every test, the rest goes with the cascade.
+const truncate = `TRUNCATE public.user, public.category, public.product RESTART IDENTITY CASCADE`
+
+// Client returns a client to a migrated and empty database.
+func Client(t *testing.T) *postgresql.UnitOfWork {
+	t.Helper()
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	logger := logging.GetLogger()
+	migrator, err := migrate.NewMigrator(pool, migrations.FS, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := pool.Exec(ctx, truncate); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return postgresql.NewUnitOfWork(pool, logger)
+}
+
+// CreateUser inserts a user for rows that reference one and returns its id.
+func CreateUser(t *testing.T, client postgresql.Client, username string) int {
+	t.Helper()
+	var id int
+	err := client.QueryRow(context.Background(),
+		`INSERT INTO public.user (username, email, password_hash) VALUES ($1, $1 || '@example.com', 'x') RETURNING id`,
+		username).Scan(&id)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return id
+}
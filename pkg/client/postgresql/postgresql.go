@@ -6,11 +6,12 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"go.mod/internal/config"
 	"go.mod/pkg/logging"
 	"go.mod/pkg/utils"
-
-	"go.mod/internal/config"
-	"time"
+	"net"
+	"net/url"
+	"strconv"
 )
 
 type Client interface {
@@ -20,23 +21,77 @@ type Client interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
-func NewClient(ctx context.Context, maxAttempts int, sc *config.Config) (pool *pgxpool.Pool, err error) {
+// NewClient connects a pool to the database of sc, retrying with exponential
+// backoff while the server is unreachable.
+func NewClient(ctx context.Context, sc *config.Config) (*pgxpool.Pool, error) {
 	logger := logging.GetLogger()
-	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", sc.Storage.Username, sc.Storage.Password, sc.Storage.Host, sc.Storage.Port, sc.Storage.Database)
-	err = utils.DoWithTries(func() error {
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	poolConfig, err := PoolConfig(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := utils.Backoff{
+		Attempts: sc.Storage.Retry.Attempts,
+		Initial:  sc.Storage.Retry.InitialBackoff,
+		Max:      sc.Storage.Retry.MaxBackoff,
+	}
+	var pool *pgxpool.Pool
+	err = utils.DoWithBackoff(ctx, backoff, func(attempt int) error {
+		ctx, cancel := context.WithTimeout(ctx, sc.Storage.ConnectTimeout)
 		defer cancel()
 
-		pool, err = pgxpool.Connect(ctx, dsn)
+		pool, err = pgxpool.ConnectConfig(ctx, poolConfig)
 		if err != nil {
+			logger.Warnf("connect to postgresql, attempt %d of %d: %v", attempt+1, backoff.Attempts, err)
 			return err
 		}
-
 		return nil
-	}, maxAttempts, 5*time.Second)
+	})
 	if err != nil {
-		logger.Fatal("error do with tries postgresql")
+		return nil, fmt.Errorf("connect to postgresql at %s: %w", poolConfig.ConnConfig.Host, err)
 	}
-
 	return pool, nil
 }
+
+// PoolConfig builds the pool configuration of sc. The password and the other
+// DSN values are escaped, so they may contain any character.
+func PoolConfig(sc *config.Config) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(DSN(sc))
+	if err != nil {
+		return nil, fmt.Errorf("postgresql configuration: %w", err)
+	}
+	poolConfig.MaxConns = sc.Storage.Pool.MaxConns
+	poolConfig.MinConns = sc.Storage.Pool.MinConns
+	poolConfig.MaxConnLifetime = sc.Storage.Pool.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = sc.Storage.Pool.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = sc.Storage.Pool.HealthCheckPeriod
+	return poolConfig, nil
+}
+
+// DSN returns the connection URL of sc. It holds the password, never log it.
+func DSN(sc *config.Config) string {
+	s := sc.Storage
+	query := url.Values{}
+	query.Set("sslmode", s.SSLMode)
+	if s.SSLRootCert != "" {
+		query.Set("sslrootcert", s.SSLRootCert)
+	}
+	if s.SSLCert != "" {
+		query.Set("sslcert", s.SSLCert)
+		query.Set("sslkey", s.SSLKey)
+	}
+	if s.ApplicationName != "" {
+		query.Set("application_name", s.ApplicationName)
+	}
+	query.Set("connect_timeout", strconv.Itoa(int(s.ConnectTimeout.Seconds())))
+	query.Set("statement_timeout", strconv.FormatInt(s.StatementTimeout.Milliseconds(), 10))
+
+	dsn := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(s.Username, s.Password),
+		Host:     net.JoinHostPort(s.Host, s.Port),
+		Path:     "/" + s.Database,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
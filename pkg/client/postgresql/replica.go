@@ -0,0 +1,214 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.mod/internal/config"
+	"go.mod/pkg/logging"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Targets and reasons of the routing decision, used in logs, spans and metrics.
+const (
+	targetPrimary = "primary"
+	targetReplica = "replica"
+
+	reasonTx        = "transaction"
+	reasonWrite     = "write"
+	reasonForced    = "read_your_writes"
+	reasonReplica   = "read"
+	reasonNoReplica = "no_healthy_replica"
+	reasonFailover  = "failover"
+)
+
+// replica is a read-only pool and whether its last health check passed.
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+type routingKey struct{}
+
+// routing is shared by every context derived from the one that installed it,
+// so a write marks the whole request.
+type routing struct {
+	primary atomic.Bool
+}
+
+// WithPrimary returns a context whose reads all go to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	r := &routing{}
+	r.primary.Store(true)
+	return context.WithValue(ctx, routingKey{}, r)
+}
+
+// TrackWrites returns a context whose reads go to the primary once a write was
+// sent through it, so a request reads its own writes even when replicas lag.
+func TrackWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(routingKey{}).(*routing); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, routingKey{}, &routing{})
+}
+
+func markWrite(ctx context.Context) {
+	if r, ok := ctx.Value(routingKey{}).(*routing); ok {
+		r.primary.Store(true)
+	}
+}
+
+func primaryRequested(ctx context.Context) bool {
+	r, ok := ctx.Value(routingKey{}).(*routing)
+	return ok && r.primary.Load()
+}
+
+// isReadOnly reports whether sql can run on a replica. Anything but a plain
+// SELECT, including CTEs that may hide a write, stays on the primary.
+func isReadOnly(sql string) bool {
+	sql = strings.TrimSpace(sql)
+	if len(sql) < 6 || !strings.EqualFold(sql[:6], "select") {
+		return false
+	}
+	upper := strings.ToUpper(sql)
+	return !strings.Contains(upper, "FOR UPDATE") && !strings.Contains(upper, "FOR SHARE") &&
+		!strings.Contains(upper, "NEXTVAL(")
+}
+
+// NewReplicas creates a pool for every replica of sc, with the credentials
+// and options of the primary. The pools connect lazily so that an unreachable
+// replica does not prevent startup.
+func NewReplicas(sc *config.Config) ([]*pgxpool.Pool, error) {
+	pools := make([]*pgxpool.Pool, 0, len(sc.Storage.Replicas))
+	for _, address := range sc.Storage.Replicas {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			host, port = address, sc.Storage.Port
+		}
+		poolConfig, err := PoolConfig(sc)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("replica %s: invalid port %q", address, port)
+		}
+		poolConfig.ConnConfig.Host = host
+		poolConfig.ConnConfig.Port = uint16(n)
+		poolConfig.ConnConfig.Fallbacks = nil
+		poolConfig.LazyConnect = true
+		pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
+		if err != nil {
+			return nil, fmt.Errorf("replica %s: %w", address, err)
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// reader picks the pool a read-only query goes to: the next healthy replica
+// in turn, or the primary when reads are forced there or no replica is up.
+func (u *UnitOfWork) reader(ctx context.Context, sql string) (*replica, string) {
+	switch {
+	case !isReadOnly(sql):
+		markWrite(ctx)
+		return nil, reasonWrite
+	case len(u.replicas) == 0:
+		return nil, reasonReplica
+	case primaryRequested(ctx):
+		return nil, reasonForced
+	}
+	start := u.next.Add(1)
+	for i := uint32(0); i < uint32(len(u.replicas)); i++ {
+		r := u.replicas[(start+i)%uint32(len(u.replicas))]
+		if r.healthy.Load() {
+			return r, reasonReplica
+		}
+	}
+	return nil, reasonNoReplica
+}
+
+// route logs and counts the routing decision of a query.
+func (u *UnitOfWork) route(ctx context.Context, r *replica, reason string) string {
+	target, name := targetPrimary, targetPrimary
+	if r != nil {
+		target, name = targetReplica, r.name
+	}
+	routedQueries.WithLabelValues(target, reason).Inc()
+	logging.FromContext(ctx).Tracef("route query to %s (%s)", name, reason)
+	return target
+}
+
+// failed takes a replica out of rotation when err shows it is unreachable,
+// the query can then be sent to the primary. Errors reported by the server
+// and cancellations of ctx say nothing about the replica.
+func (u *UnitOfWork) failed(ctx context.Context, r *replica, err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) || ctx.Err() != nil {
+		return false
+	}
+	if r.healthy.Swap(false) {
+		replicaHealthy.WithLabelValues(r.name).Set(0)
+		u.logger.Warnf("replica %s is unreachable, reads fail over to the primary: %v", r.name, err)
+	}
+	return true
+}
+
+// WatchReplicas checks every replica each interval until ctx is done. A
+// replica is taken out of rotation while it does not answer, or while it
+// lags behind the primary by more than maxLag when maxLag is positive.
+func (u *UnitOfWork) WatchReplicas(ctx context.Context, interval, maxLag time.Duration) {
+	if len(u.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, r := range u.replicas {
+			u.checkReplica(ctx, r, interval, maxLag)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *UnitOfWork) checkReplica(ctx context.Context, r *replica, timeout, maxLag time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// An idle replica that replayed everything it received is not lagging,
+	// however old its last replayed transaction is.
+	var lag float64
+	err := r.pool.QueryRow(ctx, `
+		SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END
+	`).Scan(&lag)
+	if err == nil && maxLag > 0 && time.Duration(lag*float64(time.Second)) > maxLag {
+		err = fmt.Errorf("replication lag %.1fs exceeds %s", lag, maxLag)
+	}
+	replicaLag.WithLabelValues(r.name).Set(lag)
+
+	healthy := err == nil
+	if r.healthy.Swap(healthy) != healthy {
+		if healthy {
+			u.logger.Infof("replica %s is back in rotation", r.name)
+		} else {
+			u.logger.Warnf("replica %s is out of rotation: %v", r.name, err)
+		}
+	}
+	if healthy {
+		replicaHealthy.WithLabelValues(r.name).Set(1)
+	} else {
+		replicaHealthy.WithLabelValues(r.name).Set(0)
+	}
+}
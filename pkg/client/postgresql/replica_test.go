@@ -0,0 +1,114 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go.mod/pkg/logging"
+	"testing"
+)
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT id FROM public.product", true},
+		{"\n\t  select id from public.product where id = $1", true},
+		{"SELECT id FROM public.product FOR UPDATE", false},
+		{"SELECT id FROM public.product for share", false},
+		{"SELECT nextval('product_id_seq')", false},
+		{"WITH deleted AS (DELETE FROM public.product RETURNING id) SELECT id FROM deleted", false},
+		{"INSERT INTO public.product (title) VALUES ($1)", false},
+		{"UPDATE public.product SET title = $1", false},
+		{"SELECT pg_notify('cache_invalidation', $1)", true},
+		{"sel", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isReadOnly(tt.sql), tt.sql)
+	}
+}
+
+func newTestUnitOfWork(replicas ...string) *UnitOfWork {
+	u := &UnitOfWork{logger: logging.GetLogger()}
+	for _, name := range replicas {
+		r := &replica{name: name}
+		r.healthy.Store(true)
+		u.replicas = append(u.replicas, r)
+	}
+	return u
+}
+
+func name(r *replica) string {
+	if r == nil {
+		return targetPrimary
+	}
+	return r.name
+}
+
+func TestReaderRouting(t *testing.T) {
+	const read = "SELECT 1"
+	ctx := context.Background()
+
+	r, reason := newTestUnitOfWork().reader(ctx, read)
+	assert.Nil(t, r)
+	assert.Equal(t, reasonReplica, reason, "without replicas reads stay on the primary")
+
+	u := newTestUnitOfWork("replica-1", "replica-2")
+	seen := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		r, reason := u.reader(ctx, read)
+		assert.Equal(t, reasonReplica, reason)
+		seen[name(r)]++
+	}
+	assert.Equal(t, map[string]int{"replica-1": 2, "replica-2": 2}, seen, "reads rotate over the replicas")
+
+	r, reason = u.reader(ctx, "UPDATE public.product SET title = $1")
+	assert.Nil(t, r)
+	assert.Equal(t, reasonWrite, reason)
+
+	r, reason = u.reader(WithPrimary(ctx), read)
+	assert.Nil(t, r)
+	assert.Equal(t, reasonForced, reason)
+
+	tracked := TrackWrites(ctx)
+	r, _ = u.reader(tracked, read)
+	assert.NotNil(t, r, "reads before a write may use a replica")
+	u.reader(tracked, "DELETE FROM public.product")
+	r, reason = u.reader(tracked, read)
+	assert.Nil(t, r)
+	assert.Equal(t, reasonForced, reason, "reads after a write go to the primary")
+	assert.Equal(t, tracked, TrackWrites(tracked), "tracking is installed once per request")
+
+	u.replicas[0].healthy.Store(false)
+	for i := 0; i < 3; i++ {
+		r, _ := u.reader(ctx, read)
+		assert.Equal(t, "replica-2", name(r), "unhealthy replicas are skipped")
+	}
+	u.replicas[1].healthy.Store(false)
+	r, reason = u.reader(ctx, read)
+	assert.Nil(t, r)
+	assert.Equal(t, reasonNoReplica, reason)
+}
+
+func TestFailedReplica(t *testing.T) {
+	u := newTestUnitOfWork("replica-1")
+	r := u.replicas[0]
+	ctx := context.Background()
+
+	assert.False(t, u.failed(ctx, r, &pgconn.PgError{Code: "42P01"}), "server errors are not failed over")
+	assert.True(t, r.healthy.Load())
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, u.failed(cancelled, r, context.Canceled), "cancelled requests are not failed over")
+	assert.True(t, r.healthy.Load())
+
+	assert.True(t, u.failed(ctx, r, errors.New("dial tcp: connection refused")))
+	assert.False(t, r.healthy.Load(), "an unreachable replica leaves the rotation")
+	next, reason := u.reader(ctx, "SELECT 1")
+	assert.Nil(t, next)
+	assert.Equal(t, reasonNoReplica, reason)
+}
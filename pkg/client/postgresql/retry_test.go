@@ -0,0 +1,39 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: serializationFailure}, true},
+		{&pgconn.PgError{Code: deadlockDetected}, true},
+		{fmt.Errorf("update product: %w", &pgconn.PgError{Code: serializationFailure}), true},
+		{fmt.Errorf("update product: %v", &pgconn.PgError{Code: serializationFailure}), false},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{context.Canceled, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isRetryable(tt.err), "%v", tt.err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range []time.Duration{10, 20, 40, 80, 160, 320, 500, 500} {
+		want *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			delay := backoff(attempt)
+			assert.GreaterOrEqual(t, delay, want/2, "attempt %d", attempt)
+			assert.LessOrEqual(t, delay, want, "attempt %d", attempt)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.mod/pkg/logging"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// Transactor runs fn inside a database transaction carried by ctx. Every
+// repository built on the UnitOfWork client picks that transaction up, so
+// multi-step service operations commit or roll back as a whole.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+// UnitOfWork is a Client that routes queries to the transaction found in the
+// context, and to the pool when there is none. Read-only queries outside a
+// transaction are spread over the healthy replicas.
+type UnitOfWork struct {
+	pool       *pgxpool.Pool
+	replicas   []*replica
+	next       atomic.Uint32
+	options    pgx.TxOptions
+	maxRetries int
+	logger     *logging.Logger
+}
+
+var _ Client = &UnitOfWork{}
+var _ Transactor = &UnitOfWork{}
+
+func NewUnitOfWork(pool *pgxpool.Pool, logger *logging.Logger, replicas ...*pgxpool.Pool) *UnitOfWork {
+	u := &UnitOfWork{
+		pool:       pool,
+		options:    pgx.TxOptions{IsoLevel: pgx.RepeatableRead},
+		maxRetries: 5,
+		logger:     logger,
+	}
+	for i, pool := range replicas {
+		r := &replica{name: fmt.Sprintf("replica-%d", i+1), pool: pool}
+		r.healthy.Store(true)
+		replicaHealthy.WithLabelValues(r.name).Set(1)
+		u.replicas = append(u.replicas, r)
+	}
+	return u
+}
+
+func txFromContext(ctx context.Context) pgx.Tx {
+	tx, _ := ctx.Value(txKey{}).(pgx.Tx)
+	return tx
+}
+
+// InTx reports whether ctx carries a transaction.
+func InTx(ctx context.Context) bool {
+	return txFromContext(ctx) != nil
+}
+
+func (u *UnitOfWork) Exec(ctx context.Context, sql string, arguments ...interface{}) (tag pgconn.CommandTag, err error) {
+	markWrite(ctx)
+	if tx := txFromContext(ctx); tx != nil {
+		ctx, end := startQuery(ctx, sql, u.route(ctx, nil, reasonTx))
+		defer func() { end(err) }()
+		return tx.Exec(ctx, sql, arguments...)
+	}
+	ctx, end := startQuery(ctx, sql, u.route(ctx, nil, reasonWrite))
+	defer func() { end(err) }()
+	return u.pool.Exec(ctx, sql, arguments...)
+}
+
+// Query sends a read-only query to a replica when one is healthy, and retries
+// it on the primary when the replica turns out to be unreachable.
+func (u *UnitOfWork) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return u.query(ctx, tx, sql, u.route(ctx, nil, reasonTx), args)
+	}
+	r, reason := u.reader(ctx, sql)
+	if r != nil {
+		rows, err := u.query(ctx, r.pool, sql, u.route(ctx, r, reason), args)
+		if err == nil || !u.failed(ctx, r, err) {
+			return rows, err
+		}
+		reason = reasonFailover
+	}
+	return u.query(ctx, u.pool, sql, u.route(ctx, nil, reason), args)
+}
+
+// querier is the query API shared by pools and transactions.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// query keeps the span of the query open until its rows are closed, so that
+// it covers the time spent reading them.
+func (u *UnitOfWork) query(ctx context.Context, q querier, sql, target string, args []interface{}) (pgx.Rows, error) {
+	ctx, end := startQuery(ctx, sql, target)
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		end(err)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, end: end}, nil
+}
+
+// QueryRow errors surface on Scan, which also ends the span of the query. An
+// unreachable replica is only noticed by the next health check.
+func (u *UnitOfWork) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if tx := txFromContext(ctx); tx != nil {
+		return u.queryRow(ctx, tx, sql, u.route(ctx, nil, reasonTx), args)
+	}
+	var q querier = u.pool
+	r, reason := u.reader(ctx, sql)
+	if r != nil {
+		q = r.pool
+	}
+	return u.queryRow(ctx, q, sql, u.route(ctx, r, reason), args)
+}
+
+func (u *UnitOfWork) queryRow(ctx context.Context, q querier, sql, target string, args []interface{}) pgx.Row {
+	ctx, end := startQuery(ctx, sql, target)
+	return &instrumentedRow{Row: q.QueryRow(ctx, sql, args...), end: end}
+}
+
+// Begin starts a transaction, or a savepoint when ctx already carries one.
+// Transactions always run on the primary.
+func (u *UnitOfWork) Begin(ctx context.Context) (pgx.Tx, error) {
+	markWrite(ctx)
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Begin(ctx)
+	}
+	return u.pool.BeginTx(ctx, u.options)
+}
+
+// Pool returns the underlying connection pool.
+func (u *UnitOfWork) Pool() *pgxpool.Pool {
+	return u.pool
+}
+
+// WithinTx runs fn in a repeatable read transaction, see WithinTxOptions.
+func (u *UnitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.WithinTxOptions(ctx, u.options, fn)
+}
+
+// WithinTxOptions runs fn in a new transaction, committing when it returns nil
+// and rolling back otherwise. Called inside another transaction it opens a
+// savepoint instead, and the options are ignored. The outermost call retries
+// the whole fn when Postgres aborts it with a serialization failure or a
+// deadlock, so fn must not have side effects outside the database.
+func (u *UnitOfWork) WithinTxOptions(ctx context.Context, options pgx.TxOptions, fn func(ctx context.Context) error) error {
+	markWrite(ctx)
+	if outer := txFromContext(ctx); outer != nil {
+		savepoint, err := outer.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		return run(ctx, savepoint, fn)
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var tx pgx.Tx
+		tx, err = u.pool.BeginTx(ctx, options)
+		if err != nil {
+			return err
+		}
+		err = run(ctx, tx, fn)
+		if !isRetryable(err) || attempt >= u.maxRetries {
+			return err
+		}
+		logging.FromContext(ctx).Debugf("retry transaction after serialization failure, attempt %d: %v", attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+func run(ctx context.Context, tx pgx.Tx, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+	}
+	return false
+}
+
+// backoff is a jittered exponential delay, 10ms, 20ms, 40ms... capped at 500ms.
+func backoff(attempt int) time.Duration {
+	delay := 10 * time.Millisecond << attempt
+	if delay > 500*time.Millisecond {
+		delay = 500 * time.Millisecond
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,89 @@
+package postgresql_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/internal/apperror"
+	"go.mod/pkg/client/postgresql"
+	"go.mod/pkg/client/postgresql/pgtest"
+	"testing"
+)
+
+func countUsers(t *testing.T, client postgresql.Client) int {
+	t.Helper()
+	var count int
+	require.NoError(t, client.QueryRow(context.Background(), `SELECT count(*) FROM public.user`).Scan(&count))
+	return count
+}
+
+func TestWithinTxSavepoints(t *testing.T) {
+	ctx := context.Background()
+	client := pgtest.Client(t)
+	errInner := errors.New("inner failed")
+
+	err := client.WithinTx(ctx, func(ctx context.Context) error {
+		assert.True(t, postgresql.InTx(ctx))
+		pgtest.CreateUser(t, client, "outer")
+		err := client.WithinTx(ctx, func(ctx context.Context) error {
+			pgtest.CreateUser(t, client, "rolled-back")
+			return errInner
+		})
+		assert.ErrorIs(t, err, errInner)
+		return client.WithinTx(ctx, func(ctx context.Context) error {
+			pgtest.CreateUser(t, client, "inner")
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	assert.False(t, postgresql.InTx(ctx))
+	assert.Equal(t, 2, countUsers(t, client), "only the failed savepoint is rolled back")
+
+	err = client.WithinTx(ctx, func(ctx context.Context) error {
+		pgtest.CreateUser(t, client, "lost")
+		return client.WithinTx(ctx, func(ctx context.Context) error { return errInner })
+	})
+	assert.ErrorIs(t, err, errInner)
+	assert.Equal(t, 2, countUsers(t, client), "a failing outer transaction discards its savepoints")
+}
+
+func TestWithinTxRetries(t *testing.T) {
+	ctx := context.Background()
+	client := pgtest.Client(t)
+
+	attempts := 0
+	err := client.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		pgtest.CreateUser(t, client, "retried")
+		if attempts < 3 {
+			// repositories return translated errors, the pg error stays reachable
+			return apperror.FromPostgres(&pgconn.PgError{Code: "40001", Message: "could not serialize access"})
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 1, countUsers(t, client), "failed attempts are rolled back")
+
+	attempts = 0
+	err = client.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		return apperror.FromPostgres(&pgconn.PgError{Code: "23505"})
+	})
+	assert.ErrorIs(t, err, apperror.UniqueViolation)
+	assert.Equal(t, 1, attempts, "other errors are not retried")
+
+	attempts = 0
+	err = client.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		return client.WithinTx(ctx, func(ctx context.Context) error {
+			return &pgconn.PgError{Code: "40P01"}
+		})
+	})
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "40P01", pgErr.Code)
+	assert.Equal(t, 6, attempts, "a failing savepoint retries the outermost transaction, up to 5 times")
+}
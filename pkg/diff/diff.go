@@ -0,0 +1,104 @@
+package diff
+
+import "strings"
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Line is a single line of a line-level diff.
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// MaxCells bounds the table of the longest common subsequence, once the
+// common prefix and suffix are stripped, to len(a lines) * len(b lines).
+// Larger inputs are diffed as a deletion of a followed by an insertion of b.
+const MaxCells = 1 << 18
+
+// Lines returns the line-level diff turning a into b, based on the longest
+// common subsequence of their lines.
+func Lines(a, b string) []Line {
+	from := splitLines(a)
+	to := splitLines(b)
+	lines := make([]Line, 0, len(from)+len(to))
+
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		lines = append(lines, Line{Op: OpEqual, Text: from[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+	lines = append(lines, middle(from[prefix:len(from)-suffix], to[prefix:len(to)-suffix])...)
+	for _, text := range from[len(from)-suffix:] {
+		lines = append(lines, Line{Op: OpEqual, Text: text})
+	}
+	return lines
+}
+
+// middle diffs what lies between the common prefix and suffix.
+func middle(from, to []string) []Line {
+	lines := make([]Line, 0, len(from)+len(to))
+	if (len(from)+1)*(len(to)+1) > MaxCells {
+		for _, text := range from {
+			lines = append(lines, Line{Op: OpDelete, Text: text})
+		}
+		for _, text := range to {
+			lines = append(lines, Line{Op: OpInsert, Text: text})
+		}
+		return lines
+	}
+
+	// lcs[i][j] holds the LCS length of from[i:] and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			lines = append(lines, Line{Op: OpEqual, Text: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: OpDelete, Text: from[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: OpInsert, Text: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		lines = append(lines, Line{Op: OpDelete, Text: from[i]})
+	}
+	for ; j < len(to); j++ {
+		lines = append(lines, Line{Op: OpInsert, Text: to[j]})
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both sides of a diff.
+func apply(lines []Line) (string, string) {
+	var from, to []string
+	for _, line := range lines {
+		if line.Op != OpInsert {
+			from = append(from, line.Text)
+		}
+		if line.Op != OpDelete {
+			to = append(to, line.Text)
+		}
+	}
+	return strings.Join(from, "\n"), strings.Join(to, "\n")
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{"both empty", "", "", []Line{}},
+		{"created", "", "a\nb", []Line{{OpInsert, "a"}, {OpInsert, "b"}}},
+		{"cleared", "a", "", []Line{{OpDelete, "a"}}},
+		{"unchanged", "a\nb", "a\nb", []Line{{OpEqual, "a"}, {OpEqual, "b"}}},
+		{"line endings", "a\r\nb", "a\nb", []Line{{OpEqual, "a"}, {OpEqual, "b"}}},
+		{"changed line", "a\nb\nc", "a\nx\nc", []Line{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "x"}, {OpEqual, "c"}}},
+		{"inserted line", "a\nc", "a\nb\nc", []Line{{OpEqual, "a"}, {OpInsert, "b"}, {OpEqual, "c"}}},
+		{"moved line", "a\nb\nc", "b\nc\na", []Line{{OpDelete, "a"}, {OpEqual, "b"}, {OpEqual, "c"}, {OpInsert, "a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Lines(tt.a, tt.b))
+		})
+	}
+}
+
+func TestLinesBoundsLargeInputs(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 2000; i++ {
+		a = append(a, "a"+strconv.Itoa(i))
+		b = append(b, "b"+strconv.Itoa(i))
+	}
+	from := "title\n" + strings.Join(a, "\n") + "\nfooter"
+	to := "title\n" + strings.Join(b, "\n") + "\nfooter"
+
+	lines := Lines(from, to)
+	assert.Len(t, lines, 4002)
+	assert.Equal(t, Line{OpEqual, "title"}, lines[0])
+	assert.Equal(t, Line{OpDelete, "a0"}, lines[1])
+	assert.Equal(t, Line{OpInsert, "b0"}, lines[2001])
+	assert.Equal(t, Line{OpEqual, "footer"}, lines[len(lines)-1])
+	gotFrom, gotTo := apply(lines)
+	assert.Equal(t, from, gotFrom)
+	assert.Equal(t, to, gotTo)
+
+	// an edit of a large text keeps the lines around it
+	edited := append([]string(nil), a...)
+	edited[1000] = "changed"
+	lines = Lines(strings.Join(a, "\n"), strings.Join(edited, "\n"))
+	changes := 0
+	for _, line := range lines {
+		if line.Op != OpEqual {
+			changes++
+		}
+	}
+	assert.Equal(t, 2, changes)
+}
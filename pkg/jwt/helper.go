@@ -17,19 +17,23 @@ var _ Helper = &helper{}
 type UserClaims struct {
 	jwt.RegisteredClaims
 	Email string `json:"email"`
+	Role  string `json:"role"`
 }
 
 type RT struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// refreshTokenTTL is how long a refresh token can be exchanged.
+const refreshTokenTTL = 100 * time.Second
+
 type helper struct {
 	Logger  *logging.Logger
-	RTCache cache.Repository
+	RTCache *cache.TypedCache[string, user.User]
 }
 
 func NewHelper(RTCache cache.Repository, logger *logging.Logger) Helper {
-	return &helper{RTCache: RTCache, Logger: logger}
+	return &helper{RTCache: cache.NewTypedCache[string, user.User](RTCache, cache.JSON), Logger: logger}
 }
 
 type Helper interface {
@@ -38,14 +42,9 @@ type Helper interface {
 }
 
 func (h *helper) UpdateRefreshToken(rt RT) ([]byte, error) {
-	defer h.RTCache.Del([]byte(rt.RefreshToken))
+	defer h.RTCache.Del(rt.RefreshToken)
 
-	userBytes, err := h.RTCache.Get([]byte(rt.RefreshToken))
-	if err != nil {
-		return nil, err
-	}
-	var u user.User
-	err = json.Unmarshal(userBytes, &u)
+	u, err := h.RTCache.Get(rt.RefreshToken)
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +66,7 @@ func (h *helper) GenerateAccessToken(u user.User) ([]byte, error) {
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 60)),
 		},
 		Email: u.Username,
+		Role:  u.Role,
 	}
 	token, err := builder.Build(claims)
 	if err != nil {
@@ -74,8 +74,7 @@ func (h *helper) GenerateAccessToken(u user.User) ([]byte, error) {
 	}
 
 	refreshTokenUuid := uuid.New()
-	userBytes, _ := json.Marshal(u)
-	err = h.RTCache.Set([]byte(refreshTokenUuid.String()), userBytes, 100)
+	err = h.RTCache.Set(refreshTokenUuid.String(), u, refreshTokenTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -4,21 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/cristalhq/jwt/v3"
+	"go.mod/internal/apperror"
 	"go.mod/internal/config"
+	"go.mod/internal/middleware"
 	"go.mod/pkg/logging"
 	"net/http"
 	"strings"
 	"time"
 )
 
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the token verified by Middleware.
+func ClaimsFromContext(ctx context.Context) (UserClaims, bool) {
+	uc, ok := ctx.Value(claimsKey{}).(UserClaims)
+	return uc, ok
+}
+
 func Middleware(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger := logging.GetLogger()
 		authHeader := strings.Split(r.Header.Get("Authorization"), "Bearer ")
 		if len(authHeader) != 2 {
 			logger.Error("Malformed token")
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("malformed token"))
+			apperror.WriteProblem(w, r, apperror.UnauthorizedError("malformed token"))
 			return
 		}
 		logger.Debug("create jwt verifier")
@@ -26,13 +35,13 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 		key := []byte(config.GetConfig().JWT.Secret)
 		verifier, err := jwt.NewVerifierHS(jwt.HS256, key)
 		if err != nil {
-			unauthorized(w, err)
+			unauthorized(w, r, err)
 			return
 		}
 		logger.Debug("parse and verify token")
 		token, err := jwt.ParseAndVerifyString(jwtToken, verifier)
 		if err != nil {
-			unauthorized(w, err)
+			unauthorized(w, r, err)
 			return
 		}
 
@@ -40,22 +49,51 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 		var uc UserClaims
 		err = json.Unmarshal(token.RawClaims(), &uc)
 		if err != nil {
-			unauthorized(w, err)
+			unauthorized(w, r, err)
 			return
 		}
 		if valid := uc.IsValidAt(time.Now()); !valid {
 			logger.Error("token has been expired")
-			unauthorized(w, err)
+			unauthorized(w, r, err)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "user_uuid", uc.ID)
+		ctx := context.WithValue(middleware.WithUserID(r.Context(), uc.ID), "user_uuid", uc.ID)
+		ctx = context.WithValue(ctx, claimsKey{}, uc)
 		h(w, r.WithContext(ctx))
 	}
 }
 
-func unauthorized(w http.ResponseWriter, err error) {
+// Optional is Middleware for routes anonymous users may call as well, a
+// request without an Authorization header reaches h without claims.
+func Optional(h http.HandlerFunc) http.HandlerFunc {
+	authenticated := Middleware(h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			h(w, r)
+			return
+		}
+		authenticated(w, r)
+	}
+}
+
+// RequireRole is Middleware that also rejects tokens whose role is not one
+// of roles with 403.
+func RequireRole(h http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return Middleware(func(w http.ResponseWriter, r *http.Request) {
+		uc, _ := ClaimsFromContext(r.Context())
+		for _, role := range roles {
+			if uc.Role == role {
+				h(w, r)
+				return
+			}
+		}
+		logging.GetLogger().Errorf("user %s with role %q is not allowed to %s %s", uc.ID, uc.Role, r.Method, r.URL.Path)
+		apperror.WriteProblem(w, r, apperror.ForbiddenError("insufficient role"))
+	})
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
 	logging.GetLogger().Error(err)
-	w.WriteHeader(http.StatusUnauthorized)
-	w.Write([]byte("unauthorized"))
+	apperror.WriteProblem(w, r, apperror.UnauthorizedError("unauthorized"))
 }
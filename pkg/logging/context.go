@@ -0,0 +1,24 @@
+package logging
+
+import "context"
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx that carries l.
+func ContextWithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, so log lines written while
+// serving a request keep its fields. It falls back to the global logger.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+		return l
+	}
+	return GetLogger()
+}
+
+// WithFields returns a logger that adds fields to every entry.
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+	return &Logger{l.Entry.WithFields(fields)}
+}
@@ -0,0 +1,204 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// modulePrefix is stripped from function names so package levels are keyed
+// by paths such as internal/apps/product/db.
+const modulePrefix = "go.mod/"
+
+// levels is replaced as a whole on every change so entries can read it
+// without locking.
+type levels struct {
+	defaultLevel logrus.Level
+	packages     map[string]logrus.Level
+	// resolved caches the level found for a calling function.
+	resolved sync.Map
+}
+
+var current atomic.Pointer[levels]
+
+// LevelConfig describes the levels in effect.
+type LevelConfig struct {
+	Default  string            `json:"default"`
+	Packages map[string]string `json:"packages"`
+}
+
+func newLevels(defaultLevel string, packages map[string]string) (*levels, error) {
+	l := &levels{defaultLevel: logrus.InfoLevel, packages: make(map[string]logrus.Level, len(packages))}
+	if defaultLevel != "" {
+		parsed, err := logrus.ParseLevel(defaultLevel)
+		if err != nil {
+			return nil, err
+		}
+		l.defaultLevel = parsed
+	}
+	for pkg, level := range packages {
+		parsed, err := logrus.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg, err)
+		}
+		l.packages[strings.Trim(pkg, "/")] = parsed
+	}
+	return l, nil
+}
+
+// applyLevels installs l. The logger level is lowered to the most verbose
+// level in use so that entries reach the hook, which filters per package.
+func applyLevels(l *levels) {
+	verbose := l.defaultLevel
+	for _, level := range l.packages {
+		if level > verbose {
+			verbose = level
+		}
+	}
+	current.Store(l)
+	root.SetLevel(verbose)
+}
+
+func (l *levels) levelFor(function string) logrus.Level {
+	if len(l.packages) == 0 {
+		return l.defaultLevel
+	}
+	if level, ok := l.resolved.Load(function); ok {
+		return level.(logrus.Level)
+	}
+	pkg := packageOf(function)
+	level, longest := l.defaultLevel, -1
+	for prefix, prefixLevel := range l.packages {
+		if (pkg == prefix || strings.HasPrefix(pkg, prefix+"/")) && len(prefix) > longest {
+			level, longest = prefixLevel, len(prefix)
+		}
+	}
+	l.resolved.Store(function, level)
+	return level
+}
+
+// packageOf returns the package path of a function name as reported by the
+// runtime, relative to the module.
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		function = function[:slash+1+dot]
+	}
+	return strings.TrimPrefix(function, modulePrefix)
+}
+
+func enabled(entry *logrus.Entry) bool {
+	l := current.Load()
+	level := l.defaultLevel
+	if entry.Caller != nil {
+		level = l.levelFor(entry.Caller.Function)
+	}
+	if entry.Level > level {
+		return false
+	}
+	return sampling.allow(entry.Level)
+}
+
+// Levels returns the default level and the per package overrides.
+func Levels() LevelConfig {
+	l := current.Load()
+	config := LevelConfig{Default: l.defaultLevel.String(), Packages: make(map[string]string, len(l.packages))}
+	for pkg, level := range l.packages {
+		config.Packages[pkg] = level.String()
+	}
+	return config
+}
+
+// SetLevel changes the default level at runtime.
+func SetLevel(level string) error {
+	config := Levels()
+	l, err := newLevels(level, config.Packages)
+	if err != nil {
+		return err
+	}
+	applyLevels(l)
+	return nil
+}
+
+// SetLevels replaces the default level and every package override at once.
+func SetLevels(level string, packages map[string]string) error {
+	l, err := newLevels(level, packages)
+	if err != nil {
+		return err
+	}
+	applyLevels(l)
+	return nil
+}
+
+// SetPackageLevel overrides the level of pkg and the packages below it. An
+// empty level removes the override.
+func SetPackageLevel(pkg, level string) error {
+	pkg = strings.Trim(strings.TrimPrefix(pkg, modulePrefix), "/")
+	if pkg == "" {
+		return fmt.Errorf("package must not be empty")
+	}
+	config := Levels()
+	if level == "" {
+		delete(config.Packages, pkg)
+	} else {
+		config.Packages[pkg] = level
+	}
+	l, err := newLevels(config.Default, config.Packages)
+	if err != nil {
+		return err
+	}
+	applyLevels(l)
+	return nil
+}
+
+// sampler thins out debug and trace lines when they are written faster than
+// the configured rate. Other levels are never dropped.
+type sampler struct {
+	initial    atomic.Uint64
+	thereafter atomic.Uint64
+	second     atomic.Int64
+	counts     [logrus.TraceLevel + 1]atomic.Uint64
+	dropped    atomic.Uint64
+}
+
+var sampling sampler
+
+func (s *sampler) configure(initial, thereafter int) {
+	if initial < 0 {
+		initial = 0
+	}
+	if thereafter < 0 {
+		thereafter = 0
+	}
+	s.initial.Store(uint64(initial))
+	s.thereafter.Store(uint64(thereafter))
+}
+
+func (s *sampler) allow(level logrus.Level) bool {
+	initial := s.initial.Load()
+	if level < logrus.DebugLevel || initial == 0 {
+		return true
+	}
+	now := time.Now().Unix()
+	if last := s.second.Load(); last != now && s.second.CompareAndSwap(last, now) {
+		s.counts[logrus.DebugLevel].Store(0)
+		s.counts[logrus.TraceLevel].Store(0)
+	}
+	n := s.counts[level].Add(1)
+	if n <= initial {
+		return true
+	}
+	if thereafter := s.thereafter.Load(); thereafter > 0 && (n-initial)%thereafter == 0 {
+		return true
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+// Dropped returns the number of lines discarded by sampling.
+func Dropped() uint64 {
+	return sampling.dropped.Load()
+}
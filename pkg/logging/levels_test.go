@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPackageOf(t *testing.T) {
+	tests := []struct {
+		function, want string
+	}{
+		{"go.mod/internal/apps/product/db.(*repository).FindOne", "internal/apps/product/db"},
+		{"go.mod/internal/apps/product/db.NewRepository.func1", "internal/apps/product/db"},
+		{"go.mod/cmd.start", "cmd"},
+		{"github.com/jackc/pgx/v4.(*Conn).Query", "github.com/jackc/pgx/v4"},
+		{"main.main", "main"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, packageOf(tt.function), tt.function)
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	l, err := newLevels("warning", map[string]string{
+		"internal/apps":           "info",
+		"/internal/apps/product/": "trace",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		function string
+		want     logrus.Level
+	}{
+		{"go.mod/cmd.start", logrus.WarnLevel},
+		{"go.mod/internal/apps/category/db.(*repository).FindAll", logrus.InfoLevel},
+		{"go.mod/internal/apps/product.(*service).GetById", logrus.TraceLevel},
+		{"go.mod/internal/apps/product/db.(*repository).FindOne", logrus.TraceLevel},
+		{"go.mod/internal/apps/productx.Load", logrus.InfoLevel},
+		{"go.mod/internal/application.Run", logrus.WarnLevel},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, l.levelFor(tt.function), tt.function)
+		// the second lookup is served from the resolved cache
+		require.Equal(t, tt.want, l.levelFor(tt.function), tt.function)
+	}
+
+	_, err = newLevels("loud", nil)
+	require.Error(t, err)
+	_, err = newLevels("info", map[string]string{"cmd": "loud"})
+	require.ErrorContains(t, err, "package cmd")
+}
+
+func TestSampler(t *testing.T) {
+	var s sampler
+	for i := 0; i < 10; i++ {
+		require.True(t, s.allow(logrus.TraceLevel), "sampling is off until configured")
+	}
+
+	s.configure(2, 3)
+	s.second.Store(0)
+	var allowed []int
+	for i := 1; i <= 11; i++ {
+		if s.allow(logrus.DebugLevel) {
+			allowed = append(allowed, i)
+		}
+	}
+	// the first 2 lines of a second pass, then every 3rd
+	require.Equal(t, []int{1, 2, 5, 8, 11}, allowed)
+	require.Equal(t, uint64(6), s.dropped.Load())
+
+	for i := 0; i < 100; i++ {
+		require.True(t, s.allow(logrus.InfoLevel))
+		require.True(t, s.allow(logrus.ErrorLevel))
+	}
+
+	s.configure(1, 0)
+	s.second.Store(0)
+	require.True(t, s.allow(logrus.TraceLevel))
+	require.False(t, s.allow(logrus.TraceLevel))
+	require.True(t, s.allow(logrus.DebugLevel), "levels are counted separately")
+
+	s.configure(-1, -1)
+	require.True(t, s.allow(logrus.TraceLevel))
+}
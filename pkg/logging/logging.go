@@ -3,33 +3,48 @@ package logging
 import (
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 	"io"
 	"os"
 	"path"
 	"runtime"
+	"sync"
 )
 
 type writerHook struct {
-	Writer    []io.Writer
-	LogLevels []logrus.Level
+	mu     sync.Mutex
+	writer io.Writer
 }
 
 func (hook *writerHook) Fire(entry *logrus.Entry) error {
-	line, err := entry.String()
+	if !enabled(entry) {
+		return nil
+	}
+	line, err := entry.Bytes()
 	if err != nil {
 		return err
 	}
-	for _, w := range hook.Writer {
-		w.Write([]byte(line))
-	}
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	_, err = hook.writer.Write(line)
 	return err
 }
 
-func (hook writerHook) Levels() []logrus.Level {
-	return hook.LogLevels
+func (hook *writerHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *writerHook) setWriter(w io.Writer) {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	hook.writer = w
 }
 
-var e *logrus.Entry
+var (
+	e    *logrus.Entry
+	root *logrus.Logger
+	hook *writerHook
+)
 
 type Logger struct {
 	*logrus.Entry
@@ -39,34 +54,110 @@ func GetLogger() *Logger {
 	return &Logger{e}
 }
 
+// FileOptions configures the rotated log file.
+type FileOptions struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+type Options struct {
+	// Format is text or json.
+	Format string
+	// Level is the default level, Packages overrides it per package path
+	// relative to the module, e.g. internal/apps/product/db.
+	Level    string
+	Packages map[string]string
+	// Outputs lists stdout, stderr and file.
+	Outputs []string
+	File    FileOptions
+	// Up to SampleInitial debug and trace lines per level are written each
+	// second, then only every SampleThereafter-th. Zero disables sampling.
+	SampleInitial    int
+	SampleThereafter int
+}
+
+// init sets up logging to stdout at info level so that messages written
+// before Init, such as those about reading the configuration, are not lost.
 func init() {
-	l := logrus.New()
-	l.SetReportCaller(true)
-	l.Formatter = &logrus.TextFormatter{
-		CallerPrettyfier: func(frame *runtime.Frame) (function string, file string) {
-			filename := path.Base(frame.File)
-			return fmt.Sprintf("%s()", frame.Function), fmt.Sprintf("%s:%d", filename, frame.Line)
-		},
-		DisableColors: false,
-		FullTimestamp: true,
-	}
-	err := os.MkdirAll("logs", 0777)
+	root = logrus.New()
+	root.SetReportCaller(true)
+	root.SetOutput(io.Discard)
+	root.Formatter = textFormatter()
+
+	hook = &writerHook{writer: os.Stdout}
+	root.AddHook(hook)
+
+	applyLevels(&levels{defaultLevel: logrus.InfoLevel})
+	e = logrus.NewEntry(root)
+}
+
+// Init applies options to every logger, including those obtained earlier.
+// The returned function closes the log file.
+func Init(options Options) (closeFn func() error, err error) {
+	formatter, err := newFormatter(options.Format)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	allFile, err := os.OpenFile("logs/all.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0777)
+	l, err := newLevels(options.Level, options.Packages)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	l.SetOutput(io.Discard)
+	closeFn = func() error { return nil }
+	var writers []io.Writer
+	for _, output := range options.Outputs {
+		switch output {
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		case "file":
+			file := &lumberjack.Logger{
+				Filename:   options.File.Path,
+				MaxSize:    options.File.MaxSizeMB,
+				MaxBackups: options.File.MaxBackups,
+				MaxAge:     options.File.MaxAgeDays,
+				Compress:   options.File.Compress,
+			}
+			writers = append(writers, file)
+			closeFn = file.Close
+		default:
+			return nil, fmt.Errorf("unknown log output %q", output)
+		}
+	}
+	if len(writers) == 0 {
+		writers = append(writers, os.Stdout)
+	}
 
-	l.AddHook(&writerHook{
-		Writer:    []io.Writer{allFile, os.Stdout},
-		LogLevels: logrus.AllLevels,
-	})
+	root.SetFormatter(formatter)
+	hook.setWriter(io.MultiWriter(writers...))
+	sampling.configure(options.SampleInitial, options.SampleThereafter)
+	applyLevels(l)
+	return closeFn, nil
+}
 
-	l.SetLevel(logrus.TraceLevel)
+func prettyCaller(frame *runtime.Frame) (function string, file string) {
+	filename := path.Base(frame.File)
+	return fmt.Sprintf("%s()", frame.Function), fmt.Sprintf("%s:%d", filename, frame.Line)
+}
 
-	e = logrus.NewEntry(l)
+func textFormatter() logrus.Formatter {
+	return &logrus.TextFormatter{
+		CallerPrettyfier: prettyCaller,
+		DisableColors:    false,
+		FullTimestamp:    true,
+	}
+}
+
+func newFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", "text":
+		return textFormatter(), nil
+	case "json":
+		return &logrus.JSONFormatter{CallerPrettyfier: prettyCaller}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
 }
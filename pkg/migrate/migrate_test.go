@@ -0,0 +1,90 @@
+package migrate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mod/migrations"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func files(names ...string) fstest.MapFS {
+	fsys := make(fstest.MapFS, len(names))
+	for _, name := range names {
+		fsys[name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+	return fsys
+}
+
+func TestLoad(t *testing.T) {
+	got, err := Load(files(
+		"0010_add_index.up.sql",
+		"0002_create_comments.up.sql",
+		"0002_create_comments.down.sql",
+		"0001_create_users.up.sql",
+		"0001_create_users.down.sql",
+		"README.md",
+		"0003_not_sql.up.txt",
+		"embed.go",
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "create_users", Up: "-- 0001_create_users.up.sql", Down: "-- 0001_create_users.down.sql"},
+		{Version: 2, Name: "create_comments", Up: "-- 0002_create_comments.up.sql", Down: "-- 0002_create_comments.down.sql"},
+		{Version: 10, Name: "add_index", Up: "-- 0010_add_index.up.sql"},
+	}, got, "ordered by version, not by name, and a missing down file is allowed")
+}
+
+func TestLoadRejectsBrokenSets(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"two names", []string{"0001_a.up.sql", "0001_b.up.sql"}, "migration 1 has two names"},
+		{"same version padded twice", []string{"0001_a.up.sql", "1_a.up.sql"}, "migration 1_a has two up files"},
+		{"down without up", []string{"0001_a.up.sql", "0002_b.down.sql"}, "migration 2_b has no up file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(files(tt.files...))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+	for i, m := range got {
+		assert.Equal(t, int64(i+1), m.Version, "versions have no gaps")
+		assert.NotEmpty(t, m.Down, "every migration can be reverted")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	up, down, err := Create(dir, "create_users")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0001_create_users.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "0001_create_users.down.sql"), down)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0007_add_roles.up.sql"), []byte("-- add_roles\n"), 0644))
+	up, _, err = Create(dir, "add_index")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0008_add_index.up.sql"), up, "numbered after the highest version")
+
+	got, err := Load(os.DirFS(dir))
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, "-- add_index\n", got[2].Up)
+	assert.Equal(t, "-- revert add_index\n", got[2].Down)
+
+	for _, name := range []string{"AddIndex", "add-index", "../escape", ""} {
+		_, _, err = Create(dir, name)
+		assert.ErrorContains(t, err, "must be snake_case", name)
+	}
+}
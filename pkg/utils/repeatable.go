@@ -1,15 +1,53 @@
 package utils
 
-import "time"
-
-func DoWithTries(fn func() error, attempts int, delay time.Duration) (err error) {
-	for attempts > 0 {
-		if err = fn(); err != nil {
-			time.Sleep(delay)
-			attempts--
-			continue
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Backoff describes how often and how fast an operation is retried. The delay
+// doubles after every failed attempt up to Max.
+type Backoff struct {
+	Attempts int
+	Initial  time.Duration
+	Max      time.Duration
+}
+
+// Delay returns the wait before the retry following attempt, counted from 0.
+// It is a random duration up to the exponential delay ("full jitter") so that
+// instances restarted together do not retry in lockstep.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 0; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// DoWithBackoff calls fn until it succeeds, the attempts are used up or ctx is
+// done, and returns the last error of fn.
+func DoWithBackoff(ctx context.Context, b Backoff, fn func(attempt int) error) (err error) {
+	for attempt := 0; attempt < b.Attempts; attempt++ {
+		if err = fn(attempt); err == nil {
+			return nil
+		}
+		if attempt == b.Attempts-1 {
+			break
+		}
+		timer := time.NewTimer(b.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
 		}
-		return nil
 	}
-	return
+	return err
 }